@@ -7,7 +7,11 @@ import (
 	"net/http"
 
 	"github.com/aakritigkmit/payment-gateway/internal/config"
+	"github.com/aakritigkmit/payment-gateway/internal/providers"
+	"github.com/aakritigkmit/payment-gateway/internal/repository"
 	"github.com/aakritigkmit/payment-gateway/internal/routes"
+	"github.com/aakritigkmit/payment-gateway/internal/scheduler"
+	"github.com/aakritigkmit/payment-gateway/internal/services"
 	"github.com/aakritigkmit/payment-gateway/internal/utils"
 
 	"github.com/go-chi/chi/v5"
@@ -15,8 +19,11 @@ import (
 )
 
 type App struct {
-	router *chi.Mux
-	db     *mongo.Database
+	router    *chi.Mux
+	db        *mongo.Database
+	scheduler *scheduler.Scheduler
+	expirer   *services.OrderExpirer
+	webhooks  *services.WebhookService
 }
 
 // NewApp initializes a new application instance.
@@ -40,9 +47,38 @@ func NewApp() (*App, error) {
 	router := chi.NewRouter()
 	routes.SetupRoutes(router, db)
 
+	// Background workers get their own service instances over the same database as
+	// the HTTP routes; all shared state lives in Mongo and Redis.
+	cfg := config.GetConfig()
+	orderRepo := repository.NewOrderRepo(db)
+	webhookService := services.NewWebhookService(repository.NewWebhookRepo(db), cfg)
+	refundRepo := repository.NewRefundRepo(db)
+	orderService := services.NewOrderService(orderRepo, repository.NewTransactionRepo(db), refundRepo, providers.NewRegistry(cfg), webhookService)
+	reconciler := services.NewOrderReconciler(orderService, orderRepo, repository.NewReconciliationRepo(db), cfg)
+	expirer := services.NewOrderExpirer(orderService, orderRepo, cfg)
+	refundTracker := services.NewRefundTracker(orderService, refundRepo, cfg)
+	productService := services.NewProductService(
+		repository.NewProductRepo(db),
+		repository.NewProductPriceHistoryRepo(db),
+		repository.NewSyncProfileRepo(db),
+		repository.NewProductTransactionRepo(db),
+		repository.NewProductOrderRepo(db),
+		utils.NewDTOneClient(cfg, nil),
+		webhookService,
+	)
+
+	// Recurring jobs run on cron schedules, one replica at a time.
+	jobScheduler := scheduler.New(repository.NewJobRepo(db), cfg.SchedulerLockTTL)
+	if err := registerJobs(jobScheduler, cfg, reconciler, refundTracker, productService, services.NewJobRegistry(repository.NewBackgroundJobRepo(db))); err != nil {
+		return nil, fmt.Errorf("failed to register scheduled jobs: %w", err)
+	}
+
 	return &App{
-		router: router,
-		db:     db,
+		router:    router,
+		db:        db,
+		scheduler: jobScheduler,
+		expirer:   expirer,
+		webhooks:  webhookService,
 	}, nil
 }
 
@@ -58,6 +94,11 @@ func (a *App) Start(ctx context.Context) error {
 	// Log server start.
 	log.Println("Server running on port", cfg.Port)
 
+	// Start background workers; they stop when ctx is cancelled.
+	go a.scheduler.Start(ctx)
+	go a.expirer.Start(ctx, cfg.OrderExpiryInterval)
+	go a.webhooks.Start(ctx, cfg.WebhookDispatchInterval)
+
 	// Start the server in a goroutine.
 	errChan := make(chan error, 1)
 	go func() {
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aakritigkmit/payment-gateway/internal/config"
+	"github.com/aakritigkmit/payment-gateway/internal/scheduler"
+	"github.com/aakritigkmit/payment-gateway/internal/services"
+)
+
+// registerJobs adds the recurring jobs to s using the cron expressions in cfg.
+func registerJobs(s *scheduler.Scheduler, cfg *config.Config, reconciler *services.OrderReconciler, refunds *services.RefundTracker, products *services.ProductService, backgroundJobs *services.JobRegistry) error {
+	jobs := []struct {
+		name string
+		spec string
+		run  scheduler.JobFunc
+	}{
+		{"order_reconciliation", cfg.ReconcileCron, func(ctx context.Context) (string, error) {
+			run, err := reconciler.Run(ctx)
+			return fmt.Sprintf("checked %d, updated %d, failed %d", run.Checked, run.Updated, run.Failed), err
+		}},
+		{"refund_tracker", cfg.RefundTrackCron, func(ctx context.Context) (string, error) {
+			settled, err := refunds.Run(ctx)
+			return fmt.Sprintf("settled %d refunds", settled), err
+		}},
+		{"product_sync", cfg.ProductSyncCron, func(ctx context.Context) (string, error) {
+			profiles, err := products.ResolveSyncProfiles(ctx, nil)
+			if err != nil {
+				return "", err
+			}
+			if len(profiles) == 0 {
+				return "no enabled sync profiles", nil
+			}
+			return fmt.Sprintf("synced %d profiles", len(profiles)), products.SyncProducts(ctx, profiles, nil)
+		}},
+		{"product_report", cfg.ProductReportCron, func(ctx context.Context) (string, error) {
+			n, err := products.GenerateCatalogReport(ctx)
+			return fmt.Sprintf("exported %d products", n), err
+		}},
+		{"background_job_reaper", cfg.JobReaperCron, func(ctx context.Context) (string, error) {
+			n, err := backgroundJobs.FailStaleJobs(ctx)
+			return fmt.Sprintf("failed %d stale jobs", n), err
+		}},
+		{"transaction_sweep", cfg.TransactionSweepCron, func(ctx context.Context) (string, error) {
+			n, err := products.SweepUnconfirmedTransactions(ctx, cfg.TransactionSweepLead, int64(cfg.TransactionSweepBatchSize))
+			return fmt.Sprintf("cancelled %d unconfirmed transactions", n), err
+		}},
+	}
+
+	for _, job := range jobs {
+		if err := s.Register(job.name, job.spec, job.run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+// Command migrate applies pending data migrations to the configured database.
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/aakritigkmit/payment-gateway/internal/config"
+	"github.com/aakritigkmit/payment-gateway/internal/migrations"
+	"github.com/aakritigkmit/payment-gateway/internal/utils"
+)
+
+func main() {
+	config.Init()
+
+	db, err := utils.ConnectDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer utils.Client.Disconnect(context.Background())
+
+	if err := migrations.Run(context.Background(), db); err != nil {
+		log.Fatalf("Migration error: %v", err)
+	}
+	log.Println("Migrations complete")
+}
@@ -0,0 +1,45 @@
+// Command simulator serves fake Pine Labs, DT One and DBS APIs for local development.
+// Point the gateway at it with SIMULATOR_BASE_URL.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/aakritigkmit/payment-gateway/internal/simulator"
+)
+
+func main() {
+	defaultAddr := os.Getenv("SIMULATOR_ADDR")
+	if defaultAddr == "" {
+		defaultAddr = ":9090"
+	}
+
+	addr := flag.String("addr", defaultAddr, "listen address")
+	baseURL := flag.String("base-url", "", "externally visible URL (default http://localhost<addr>)")
+	products := flag.Int("products", 150, "DT One products per simulated operator")
+	refundStatus := flag.String("refund-status", "PROCESSED", "Pine Labs status for new refunds, e.g. PENDING")
+	flag.Parse()
+
+	if *baseURL == "" {
+		host := *addr
+		if strings.HasPrefix(host, ":") {
+			host = "localhost" + host
+		}
+		*baseURL = "http://" + host
+	}
+
+	sim := simulator.New(simulator.Options{
+		ProductsPerOperator: *products,
+		RefundStatus:        *refundStatus,
+	})
+	sim.SetBaseURL(*baseURL)
+
+	log.Printf("[Simulator] Listening on %s; run the gateway with SIMULATOR_BASE_URL=%s", *addr, *baseURL)
+	if err := http.ListenAndServe(*addr, sim.Handler()); err != nil {
+		log.Fatalf("Simulator error: %v", err)
+	}
+}
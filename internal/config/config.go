@@ -5,6 +5,8 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -26,13 +28,71 @@ type Config struct {
 	PinelabsOrderURL     string
 	PinelabsGetOrderURL  string
 	PinelabsRefundURL    string
+	PinelabsCaptureURL   string
+	PinelabsCancelURL    string
 
-	// Dt-one specific credentials
-	DtOneUsername          string
-	DtOnePassword          string
-	DtOneProductsURL       string
-	DtOneTransactionURL    string
-	DtOneGetTransactionURL string
+	// Pinelabs callback verification
+	PinelabsWebhookSecret     string
+	PinelabsCallbackTolerance time.Duration
+
+	// Payment provider routing
+	PaymentProvider          string
+	PaymentFallbackProvider  string
+	MerchantPaymentProviders map[string]string
+
+	// Dt-one specific credentials. DtOneBaseURL comes from DT_ONE_BASE_URL, which
+	// replaces DT_ONE_PRODUCTS_URL, DT_ONE_TRANSACTION_URL and DT_ONE_GET_TRANSACTION_URL;
+	// see dtOneBaseURL for how deployments still setting those are handled.
+	DtOneUsername   string
+	DtOnePassword   string
+	DtOneBaseURL    string
+	DtOneTimeout    time.Duration
+	DtOneMaxRetries int
+
+	// DT One sends transaction status callbacks to DtOneCallbackURL, authenticating with
+	// these credentials. An empty URL leaves the callback URL configured on the account.
+	DtOneCallbackURL      string
+	DtOneCallbackUsername string
+	DtOneCallbackPassword string
+
+	// Stale order reconciliation
+	ReconcileStaleAfter  time.Duration
+	ReconcileConcurrency int
+	ReconcileBatchSize   int
+
+	// Unpaid orders are expired after OrderTTL
+	OrderTTL             time.Duration
+	OrderExpiryInterval  time.Duration
+	OrderExpiryBatchSize int
+
+	// Pending refunds are checked with the provider on the RefundTrackCron schedule
+	// (see scheduler.ParseCron), RefundPollBatchSize at a time
+	RefundTrackCron     string
+	RefundPollBatchSize int
+
+	// Scheduled jobs, as cron expressions (see scheduler.ParseCron). An empty
+	// expression disables the job.
+	ReconcileCron     string
+	ProductSyncCron   string
+	ProductReportCron string
+	JobReaperCron     string
+	SchedulerLockTTL  time.Duration
+
+	// Unconfirmed DT One transactions are cancelled TransactionSweepLead before their
+	// confirmation expires
+	TransactionSweepCron      string
+	TransactionSweepLead      time.Duration
+	TransactionSweepBatchSize int
+
+	// Idempotency-Key records are kept this long
+	IdempotencyKeyTTL time.Duration
+
+	// Outbound merchant webhooks
+	WebhookDispatchInterval time.Duration
+	WebhookTimeout          time.Duration
+	WebhookMaxAttempts      int
+	WebhookRetryBaseDelay   time.Duration
+	WebhookRetryMaxDelay    time.Duration
 
 	// Redis configuration
 	RedisHost     string
@@ -44,6 +104,16 @@ type Config struct {
 
 var instance *Config
 
+// Paths served by cmd/simulator. When SIMULATOR_BASE_URL is set, provider URLs that
+// are not configured explicitly default to the simulator.
+const (
+	SimulatorPineLabsTokenPath    = "/pinelabs/api/auth/v1/token"
+	SimulatorPineLabsOrderPath    = "/pinelabs/api/checkout/v1/orders"
+	SimulatorPineLabsPayOrderPath = "/pinelabs/api/pay/v1/orders"
+	SimulatorPineLabsRefundPath   = "/pinelabs/api/pay/v1/refunds"
+	SimulatorDtOnePath            = "/dtone"
+)
+
 // getEnvWithDefault returns the value of the environment variable identified by key,
 // or returns defaultValue if the key is not set.
 func getEnvWithDefault(key, defaultValue string) string {
@@ -62,6 +132,79 @@ func parseEnvAsInt(key string, defaultVal int) int {
 	return defaultVal
 }
 
+// parseEnvAsMap parses a "key=value,key=value" environment variable into a map.
+func parseEnvAsMap(key string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || k == "" {
+			continue
+		}
+		result[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return result
+}
+
+// legacyDtOneURLs are the per-endpoint variables DT_ONE_BASE_URL replaced, with the
+// API paths each of them may end in.
+var legacyDtOneURLs = []struct {
+	env   string
+	paths []string
+}{
+	{"DT_ONE_PRODUCTS_URL", []string{"/v1/products"}},
+	{"DT_ONE_TRANSACTION_URL", []string{"/v1/async/transactions", "/v1/transactions"}},
+	{"DT_ONE_GET_TRANSACTION_URL", []string{"/v1/transactions"}},
+}
+
+// dtOneBaseURL returns DT_ONE_BASE_URL. When it is unset but the deprecated
+// DT_ONE_*_URL variables are, the base URL is derived from them with a warning;
+// startup fails if they do not end in a known API path or disagree on the base.
+func dtOneBaseURL(sim string) string {
+	if base := os.Getenv("DT_ONE_BASE_URL"); base != "" {
+		return base
+	}
+
+	base := ""
+	for _, legacy := range legacyDtOneURLs {
+		value := strings.TrimRight(os.Getenv(legacy.env), "/")
+		if value == "" {
+			continue
+		}
+		derived := ""
+		for _, path := range legacy.paths {
+			if strings.HasSuffix(value, path) {
+				derived = strings.TrimSuffix(value, path)
+				break
+			}
+		}
+		if derived == "" {
+			log.Fatalf("%s=%s does not end in %s; set DT_ONE_BASE_URL instead", legacy.env, value, strings.Join(legacy.paths, " or "))
+		}
+		if base != "" && derived != base {
+			log.Fatalf("DT One URLs point at different hosts (%s, %s); set DT_ONE_BASE_URL instead", base, derived)
+		}
+		base = derived
+	}
+	if base != "" {
+		log.Printf("DT_ONE_PRODUCTS_URL, DT_ONE_TRANSACTION_URL and DT_ONE_GET_TRANSACTION_URL are deprecated; using DT_ONE_BASE_URL=%s", base)
+		return base
+	}
+
+	base = simulatorURL(sim, SimulatorDtOnePath)
+	if base == "" {
+		log.Println("DT_ONE_BASE_URL is not set; DT One requests will fail")
+	}
+	return base
+}
+
+// simulatorURL joins the simulator base URL and path, or returns "" when no simulator is configured.
+func simulatorURL(base, path string) string {
+	if base == "" {
+		return ""
+	}
+	return strings.TrimRight(base, "/") + path
+}
+
 // Init loads the environment variables from .env (if present), then initializes the
 // Config singleton using only the required environment variables.
 // Note: You should only use the singleton from the service layer instead of calling os.Getenv directly.
@@ -75,6 +218,8 @@ func Init() {
 		fmt.Sscanf(dbEnv, "%d", &redisDB)
 	}
 
+	sim := getEnvWithDefault("SIMULATOR_BASE_URL", "")
+
 	host := getEnvWithDefault("REDIS_HOST", "localhost")
 	port := getEnvWithDefault("REDIS_PORT", "6379")
 
@@ -89,21 +234,63 @@ func Init() {
 		PinelabsClientID:     getEnvWithDefault("PINELABS_CLIENT_ID", ""),
 		PinelabsClientSecret: getEnvWithDefault("PINELABS_CLIENT_SECRET", ""),
 		PinelabsGrantType:    getEnvWithDefault("PINELABS_GRANT_TYPE", "client_credentials"),
-		PinelabsTokenURL:     getEnvWithDefault("PINELABS_TOKEN_URL", ""),
-		PinelabsOrderURL:     getEnvWithDefault("PINELABS_ORDER_URL", ""),
-		PinelabsGetOrderURL:  getEnvWithDefault("PINELABS_GET_ORDER_URL", ""),
-		PinelabsRefundURL:    getEnvWithDefault("PINELABS_REFUND_URL", ""),
-
-		DtOneUsername:          getEnvWithDefault("DT_ONE_USERNAME", ""),
-		DtOnePassword:          getEnvWithDefault("DT_ONE_PASSWORD", ""),
-		DtOneProductsURL:       getEnvWithDefault("DT_ONE_PRODUCTS_URL", ""),
-		DtOneTransactionURL:    getEnvWithDefault("DT_ONE_TRANSACTION_URL", ""),
-		DtOneGetTransactionURL: getEnvWithDefault("DT_ONE_GET_TRANSACTION_URL", ""),
-		RedisHost:              host,
-		RedisPort:              port,
-		RedisPassword:          getEnvWithDefault("REDIS_PASSWORD", ""),
-		RedisDB:                redisDB,
-		RedisAddr:              host + ":" + port,
+		PinelabsTokenURL:     getEnvWithDefault("PINELABS_TOKEN_URL", simulatorURL(sim, SimulatorPineLabsTokenPath)),
+		PinelabsOrderURL:     getEnvWithDefault("PINELABS_ORDER_URL", simulatorURL(sim, SimulatorPineLabsOrderPath)),
+		PinelabsGetOrderURL:  getEnvWithDefault("PINELABS_GET_ORDER_URL", simulatorURL(sim, SimulatorPineLabsPayOrderPath)),
+		PinelabsRefundURL:    getEnvWithDefault("PINELABS_REFUND_URL", simulatorURL(sim, SimulatorPineLabsRefundPath)),
+		PinelabsCaptureURL:   getEnvWithDefault("PINELABS_CAPTURE_URL", simulatorURL(sim, SimulatorPineLabsPayOrderPath)), // orders base URL; /{order_id}/capture is appended
+		PinelabsCancelURL:    getEnvWithDefault("PINELABS_CANCEL_URL", simulatorURL(sim, SimulatorPineLabsPayOrderPath)),  // orders base URL; /{order_id}/cancel is appended
+
+		PinelabsWebhookSecret:     getEnvWithDefault("PINELABS_WEBHOOK_SECRET", ""),
+		PinelabsCallbackTolerance: time.Duration(parseEnvAsInt("PINELABS_CALLBACK_TOLERANCE_SECONDS", 300)) * time.Second,
+
+		PaymentProvider:          getEnvWithDefault("PAYMENT_PROVIDER", "pinelabs"),
+		PaymentFallbackProvider:  getEnvWithDefault("PAYMENT_FALLBACK_PROVIDER", ""),
+		MerchantPaymentProviders: parseEnvAsMap("MERCHANT_PAYMENT_PROVIDERS"),
+
+		DtOneUsername:   getEnvWithDefault("DT_ONE_USERNAME", ""),
+		DtOnePassword:   getEnvWithDefault("DT_ONE_PASSWORD", ""),
+		DtOneBaseURL:    dtOneBaseURL(sim),
+		DtOneTimeout:    time.Duration(parseEnvAsInt("DT_ONE_TIMEOUT_SECONDS", 30)) * time.Second,
+		DtOneMaxRetries: parseEnvAsInt("DT_ONE_MAX_RETRIES", 4),
+
+		DtOneCallbackURL:      getEnvWithDefault("DT_ONE_CALLBACK_URL", ""),
+		DtOneCallbackUsername: getEnvWithDefault("DT_ONE_CALLBACK_USERNAME", ""),
+		DtOneCallbackPassword: getEnvWithDefault("DT_ONE_CALLBACK_PASSWORD", ""),
+
+		ReconcileStaleAfter:  time.Duration(parseEnvAsInt("RECONCILE_STALE_AFTER_MINUTES", 30)) * time.Minute,
+		ReconcileConcurrency: parseEnvAsInt("RECONCILE_CONCURRENCY", 5),
+		ReconcileBatchSize:   parseEnvAsInt("RECONCILE_BATCH_SIZE", 500),
+		IdempotencyKeyTTL:    time.Duration(parseEnvAsInt("IDEMPOTENCY_KEY_TTL_HOURS", 24)) * time.Hour,
+
+		OrderTTL:             time.Duration(parseEnvAsInt("ORDER_TTL_MINUTES", 60)) * time.Minute,
+		OrderExpiryInterval:  time.Duration(parseEnvAsInt("ORDER_EXPIRY_INTERVAL_MINUTES", 5)) * time.Minute,
+		OrderExpiryBatchSize: parseEnvAsInt("ORDER_EXPIRY_BATCH_SIZE", 500),
+
+		ReconcileCron:     getEnvWithDefault("RECONCILE_CRON", "*/10 * * * *"),
+		ProductSyncCron:   getEnvWithDefault("PRODUCT_SYNC_CRON", "0 */6 * * *"),
+		ProductReportCron: getEnvWithDefault("PRODUCT_REPORT_CRON", ""),
+		JobReaperCron:     getEnvWithDefault("JOB_REAPER_CRON", "* * * * *"),
+		SchedulerLockTTL:  time.Duration(parseEnvAsInt("SCHEDULER_LOCK_TTL_SECONDS", 60)) * time.Second,
+
+		TransactionSweepCron:      getEnvWithDefault("TRANSACTION_SWEEP_CRON", "* * * * *"),
+		TransactionSweepLead:      time.Duration(parseEnvAsInt("TRANSACTION_SWEEP_LEAD_MINUTES", 5)) * time.Minute,
+		TransactionSweepBatchSize: parseEnvAsInt("TRANSACTION_SWEEP_BATCH_SIZE", 100),
+
+		RefundTrackCron:     getEnvWithDefault("REFUND_TRACK_CRON", "* * * * *"),
+		RefundPollBatchSize: parseEnvAsInt("REFUND_POLL_BATCH_SIZE", 100),
+
+		WebhookDispatchInterval: time.Duration(parseEnvAsInt("WEBHOOK_DISPATCH_INTERVAL_SECONDS", 5)) * time.Second,
+		WebhookTimeout:          time.Duration(parseEnvAsInt("WEBHOOK_TIMEOUT_SECONDS", 10)) * time.Second,
+		WebhookMaxAttempts:      parseEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookRetryBaseDelay:   time.Duration(parseEnvAsInt("WEBHOOK_RETRY_BASE_SECONDS", 30)) * time.Second,
+		WebhookRetryMaxDelay:    time.Duration(parseEnvAsInt("WEBHOOK_RETRY_MAX_MINUTES", 360)) * time.Minute,
+
+		RedisHost:     host,
+		RedisPort:     port,
+		RedisPassword: getEnvWithDefault("REDIS_PASSWORD", ""),
+		RedisDB:       redisDB,
+		RedisAddr:     host + ":" + port,
 	}
 }
 
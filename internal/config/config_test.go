@@ -0,0 +1,23 @@
+package config
+
+import "testing"
+
+func TestDtOneBaseURLFromLegacyVariables(t *testing.T) {
+	t.Setenv("DT_ONE_BASE_URL", "")
+	t.Setenv("DT_ONE_PRODUCTS_URL", "https://dvs-api.dtone.com/v1/products")
+	t.Setenv("DT_ONE_TRANSACTION_URL", "https://dvs-api.dtone.com/v1/async/transactions/")
+	t.Setenv("DT_ONE_GET_TRANSACTION_URL", "https://dvs-api.dtone.com/v1/transactions")
+
+	if got, want := dtOneBaseURL(""), "https://dvs-api.dtone.com"; got != want {
+		t.Errorf("dtOneBaseURL() = %q, want %q", got, want)
+	}
+}
+
+func TestDtOneBaseURLPrefersBaseURL(t *testing.T) {
+	t.Setenv("DT_ONE_BASE_URL", "https://preprod-dvs-api.dtone.com")
+	t.Setenv("DT_ONE_PRODUCTS_URL", "https://dvs-api.dtone.com/v1/products")
+
+	if got, want := dtOneBaseURL(""), "https://preprod-dvs-api.dtone.com"; got != want {
+		t.Errorf("dtOneBaseURL() = %q, want %q", got, want)
+	}
+}
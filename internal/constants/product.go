@@ -20,6 +20,22 @@ type productServiceIDs struct {
 	ESIM       int
 }
 
+type calculationModes struct {
+	SourceAmount      string
+	DestinationAmount string
+}
+
+type transactionStatusClasses struct {
+	Created   int
+	Confirmed int
+	Rejected  int
+	Cancelled int
+	Submitted int
+	Completed int
+	Reversed  int
+	Declined  int
+}
+
 type productCountryISOCode struct {
 	Global string
 	Europe string
@@ -35,6 +51,26 @@ var ProductTypes = productTypes{
 	RangedValuePayment:     "RANGED_VALUE_PAYMENT",
 }
 
+// CalculationModes tell DT One which side of a ranged-value transaction the requested
+// amount is given in.
+var CalculationModes = calculationModes{
+	SourceAmount:      "SOURCE_AMOUNT",
+	DestinationAmount: "DESTINATION_AMOUNT",
+}
+
+// TransactionStatusClasses are DT One's transaction status class IDs. Transactions
+// created without auto-confirm stay Created until they are confirmed or cancelled.
+var TransactionStatusClasses = transactionStatusClasses{
+	Created:   1,
+	Confirmed: 2,
+	Rejected:  3,
+	Cancelled: 4,
+	Submitted: 5,
+	Completed: 6,
+	Reversed:  7,
+	Declined:  9,
+}
+
 var ProductServiceIDs = productServiceIDs{
 	Mobile:     1,
 	Utilities:  3,
@@ -52,6 +88,8 @@ var ProductCountryISOCode = productCountryISOCode{
 	India:  "IND",
 }
 
+// ProductOperatorId seeds the default sync profiles (migration 0003); sync itself reads
+// the profiles stored in Mongo.
 var ProductOperatorId = map[string]int{
 	"Roblox":    5873,
 	"Guatemala": 2060,
@@ -1,12 +1,73 @@
 package dto
 
+import (
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+)
+
 type LineItem struct {
 	ProductID int     `json:"productId"`
 	Quantity  int     `json:"quantity"`
 	Amount    float64 `json:"amount"`
+	TransactionAmount
+	// PartyFields apply to every unit of the line item. An empty credit party
+	// identifier falls back to the request's mobile number.
+	PartyFields
 }
 
 type BulkTransactionRequest struct {
 	LineItems    []LineItem `json:"lineItems"`
 	MobileNumber string     `json:"mobile_number"`
 }
+
+// Bulk order and item states reported by GET /api/products/orders/{orderId}.
+const (
+	BulkOrderProcessing         = "processing"
+	BulkOrderCompleted          = "completed"
+	BulkOrderPartiallyCompleted = "partially_completed"
+	BulkOrderFailed             = "failed"
+
+	BulkItemQueued    = "queued"
+	BulkItemCreated   = "created"
+	BulkItemFulfilled = "fulfilled"
+	BulkItemFailed    = "failed"
+)
+
+// BulkOrderCounts counts requested units by item state. Queued units have not been
+// sent to DT One yet; created ones are awaiting delivery.
+type BulkOrderCounts struct {
+	Requested int `json:"requested"`
+	Queued    int `json:"queued"`
+	Created   int `json:"created"`
+	Fulfilled int `json:"fulfilled"`
+	Failed    int `json:"failed"`
+}
+
+type BulkOrderStatusResponse struct {
+	OrderID    string                    `json:"orderId"`
+	State      string                    `json:"state"`
+	Counts     BulkOrderCounts           `json:"counts"`
+	LineItems  []BulkOrderLineItemStatus `json:"lineItems"`
+	CreatedAt  time.Time                 `json:"createdAt"`
+	UpdatedAt  time.Time                 `json:"updatedAt"`
+	FinishedAt *time.Time                `json:"finishedAt,omitempty"`
+}
+
+type BulkOrderLineItemStatus struct {
+	ProductID int                   `json:"productId"`
+	Quantity  int                   `json:"quantity"`
+	Counts    BulkOrderCounts       `json:"counts"`
+	Items     []BulkOrderItemStatus `json:"items"`
+}
+
+// BulkOrderItemStatus is one unit sent to DT One. Pin is only set for the user who
+// placed the order.
+type BulkOrderItemStatus struct {
+	ExternalID    string       `json:"externalId"`
+	TransactionID int64        `json:"transactionId,omitempty"`
+	State         string       `json:"state"`
+	DTOneStatus   model.Status `json:"dtOneStatus"`
+	Pin           *model.Pin   `json:"pin,omitempty"`
+	UpdatedAt     time.Time    `json:"updatedAt"`
+}
@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"github.com/aakritigkmit/payment-gateway/internal/model"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -104,9 +105,58 @@ type Validity struct {
 }
 
 type CreateTransactionRequest struct {
-	ExternalID   string `json:"external_id"`
-	ProductID    int    `json:"product_id"`
+	ExternalID string `json:"external_id"`
+	ProductID  int    `json:"product_id"`
+	// MobileNumber is shorthand for credit_party_identifier.mobile_number.
 	MobileNumber string `json:"mobile_number"`
+	// AutoConfirm defaults to true. When false the transaction is held until it is
+	// confirmed or cancelled, or swept shortly before its confirmation expires.
+	AutoConfirm *bool `json:"auto_confirm,omitempty"`
+	TransactionAmount
+	PartyFields
+}
+
+// TransactionAmount is the value requested for a ranged-value product, in either the
+// source (wholesale) or destination (beneficiary) currency. Fixed-value products take
+// neither.
+type TransactionAmount struct {
+	SourceAmount      *float64 `json:"source_amount,omitempty"`
+	DestinationAmount *float64 `json:"destination_amount,omitempty"`
+}
+
+// PartyFields are the identifier fields DT One asks for per product. Keys follow the
+// product's required_*_fields, e.g. mobile_number, account_number or first_name.
+type PartyFields struct {
+	CreditPartyIdentifier map[string]string `json:"credit_party_identifier,omitempty"`
+	DebitPartyIdentifier  map[string]string `json:"debit_party_identifier,omitempty"`
+	Sender                map[string]string `json:"sender,omitempty"`
+	Beneficiary           map[string]string `json:"beneficiary,omitempty"`
+	StatementIdentifier   map[string]string `json:"statement_identifier,omitempty"`
+}
+
+// DTOneTransactionRequest is the body of a DT One transaction. CalculationMode and one
+// of Source or Destination are set for ranged-value products only.
+type DTOneTransactionRequest struct {
+	ExternalID      string       `json:"external_id"`
+	ProductID       int          `json:"product_id"`
+	AutoConfirm     bool         `json:"auto_confirm"`
+	CalculationMode string       `json:"calculation_mode,omitempty"`
+	Source          *DTOneAmount `json:"source,omitempty"`
+	Destination     *DTOneAmount `json:"destination,omitempty"`
+	CallbackURL     string       `json:"callback_url,omitempty"`
+	PartyFields
+}
+
+// FieldError is one invalid field of a request. Field is the JSON path of the field,
+// e.g. "sender.last_name" or "lineItems[2].destination_amount".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type DTOneAmount struct {
+	Amount float64 `json:"amount"`
+	Unit   string  `json:"unit"`
 }
 
 type ProductSyncRequest struct {
@@ -116,6 +166,32 @@ type ProductSyncRequest struct {
 	OperatorID     int    `json:"operator_id"`
 }
 
+// SyncProductsRequest selects the sync profiles POST /api/products/sync runs. No IDs
+// means every enabled profile.
+type SyncProductsRequest struct {
+	ProfileIDs []string `json:"profile_ids,omitempty"`
+}
+
+// CreateSyncProfileRequest adds a slice of the DT One catalog to product sync.
+type CreateSyncProfileRequest struct {
+	Name           string `json:"name"`
+	ServiceID      int    `json:"service_id"`
+	Type           string `json:"type,omitempty"`
+	CountryISOCode string `json:"country_iso_code,omitempty"`
+	OperatorID     int    `json:"operator_id,omitempty"`
+	Enabled        *bool  `json:"enabled,omitempty"`
+}
+
+// UpdateSyncProfileRequest changes the fields that are present in the body.
+type UpdateSyncProfileRequest struct {
+	Name           *string `json:"name,omitempty"`
+	ServiceID      *int    `json:"service_id,omitempty"`
+	Type           *string `json:"type,omitempty"`
+	CountryISOCode *string `json:"country_iso_code,omitempty"`
+	OperatorID     *int    `json:"operator_id,omitempty"`
+	Enabled        *bool   `json:"enabled,omitempty"`
+}
+
 type ProductReportByIDRequest struct {
 	ProductIDs []int `json:"product_ids"`
 }
@@ -123,3 +199,27 @@ type ProductReportByIDRequest struct {
 type ProductSyncMappedRequest struct {
 	OperatorKeys []string `json:"operator_keys"` // e.g. ["Roblox", "PlayStation"]
 }
+
+// ProductSearchParams are the filters accepted by GET /api/products. Prices are
+// wholesale prices in major units of Currency, which a price range requires.
+type ProductSearchParams struct {
+	CountryISOCode string
+	OperatorID     int
+	ServiceID      int
+	SubServiceID   int
+	Type           string
+	Currency       string
+	MinPrice       *float64
+	MaxPrice       *float64
+	Query          string
+	// IncludeInactive also returns products that dropped out of the last sync.
+	IncludeInactive bool
+	Cursor          string
+	Limit           int64
+}
+
+// ProductListResponse is one page of GET /api/products. NextCursor is empty on the last page.
+type ProductListResponse struct {
+	Products   []model.Product `json:"products"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
@@ -3,6 +3,7 @@ package dto
 import (
 	"time"
 
+	"github.com/aakritigkmit/payment-gateway/internal/money"
 	"github.com/go-playground/validator/v10"
 )
 
@@ -47,11 +48,11 @@ type PineCheckoutResponse struct {
 }
 
 type Order struct {
-	UserID                 string  `json:"userId"`
-	TransactionReferenceId string  `json:"transactionReferenceId"`
-	Amount                 float32 `json:"amount"`
-	Currency               string  `json:"currency"`
-	Status                 string  `json:"status"` // pending, success, failure
+	UserID                 string       `json:"userId"`
+	TransactionReferenceId string       `json:"transactionReferenceId"`
+	Amount                 money.Amount `json:"amount"`
+	Currency               string       `json:"currency"`
+	Status                 string       `json:"status"` // pending, success, failure
 
 }
 
@@ -64,12 +65,12 @@ type PlaceOrderRequest struct {
 	CallbackURL            string         `json:"callback_url"`
 	FailureCallbackURL     string         `json:"failure_callback_url"`
 	PurchaseDetails        PurchaseDetail `json:"purchase_details"`
+	MerchantID             string         `json:"merchant_id,omitempty"`
+	Provider               string         `json:"provider,omitempty"` // overrides the merchant/default provider
 }
 
-type OrderAmount struct {
-	Value    float32 `json:"value"`
-	Currency string  `json:"currency"`
-}
+// OrderAmount is the Pine Labs amount object; value is in minor units (paise).
+type OrderAmount = money.Money
 
 type Customer struct {
 	EmailID         string  `json:"email_id"`
@@ -99,10 +100,24 @@ type TransactionCallbackPayload struct {
 }
 
 type UpdateOrderPayload struct {
-	Status   string  `json:"status,omitempty"`
-	Amount   float32 `json:"amount,omitempty"`
-	Currency string  `json:"currency,omitempty"`
-	UserID   string  `json:"userId,omitempty"`
+	Status   string       `json:"status,omitempty"`
+	Amount   money.Amount `json:"amount,omitempty"`
+	Currency string       `json:"currency,omitempty"`
+	UserID   string       `json:"userId,omitempty"`
+}
+
+// OrderSearchParams are the filters accepted by GET /api/orders.
+type OrderSearchParams struct {
+	CreatedBy  string
+	Status     string
+	From       time.Time
+	To         time.Time
+	CustomerID string
+	Email      string
+	Mobile     string
+	Currency   string
+	Cursor     string
+	Limit      int64
 }
 
 type Payment struct {
@@ -149,11 +164,26 @@ type PineOrderData struct {
 	PaymentRetriesRemaining int            `json:"payment_retries_remaining"`
 }
 
+// RefundRequest refunds part or all of an order. The currency always comes from the
+// stored order; a merchant refund reference is generated when none is given.
 type RefundRequest struct {
-	OrderID string `json:"order_id"`
-	// MerchantOrderReference string           `json:"merchant_order_reference"`
-	OrderAmount int `json:"order_amount"`
-	// MerchantMetadata       MerchantMetadata `json:"merchant_metadata"`
+	OrderID                 string            `json:"order_id"`
+	OrderAmount             money.Amount      `json:"order_amount"` // minor units of the order currency
+	MerchantRefundReference string            `json:"merchant_refund_reference,omitempty"`
+	Reason                  string            `json:"reason,omitempty"`
+	Metadata                map[string]string `json:"metadata,omitempty"`
+}
+
+// CaptureRequest captures a pre-authorized order. Amount is in minor units; zero captures
+// the full order amount.
+type CaptureRequest struct {
+	Amount                   money.Amount `json:"amount"`
+	MerchantCaptureReference string       `json:"merchant_capture_reference"`
+}
+
+// FailRefundRequest records why support failed a refund the provider has no record of.
+type FailRefundRequest struct {
+	Reason string `json:"reason,omitempty"`
 }
 
 type MerchantMetadata struct {
@@ -212,7 +242,7 @@ type RefundOrderData struct {
 	Type                    string          `json:"type"`
 	Status                  string          `json:"status"`
 	MerchantID              string          `json:"merchant_id"`
-	OrderAmount             Amount          `json:"order_amount"`
+	OrderAmount             OrderAmount     `json:"order_amount"`
 	PurchaseDetails         PurchaseDetails `json:"purchase_details"`
 	Payments                []Payment       `json:"payments"`
 	CreatedAt               string          `json:"created_at"`
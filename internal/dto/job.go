@@ -0,0 +1,9 @@
+package dto
+
+import "github.com/aakritigkmit/payment-gateway/internal/model"
+
+// JobRunListResponse is one page of GET /api/jobs/runs.
+type JobRunListResponse struct {
+	Runs       []model.JobRun `json:"runs"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"github.com/aakritigkmit/payment-gateway/internal/money"
+)
+
+// OrderDetailResponse is returned by GET /api/orders/{id}.
+type OrderDetailResponse struct {
+	Order        model.Order         `json:"order"`
+	Transaction  *model.Transaction  `json:"transaction,omitempty"`
+	Payments     []model.Payment     `json:"payments"`
+	Refunds      []model.Refund      `json:"refunds"`
+	RefundLedger []model.RefundEntry `json:"refund_ledger"`
+}
+
+// OrderRefundsResponse is returned by GET /api/orders/{id}/refunds.
+type OrderRefundsResponse struct {
+	OrderID             string              `json:"order_id"`
+	Status              model.OrderStatus   `json:"status"`
+	Currency            string              `json:"currency"`
+	CapturedAmount      money.Amount        `json:"captured_amount"`
+	RefundedAmount      money.Amount        `json:"refunded_amount"`
+	RefundPendingAmount money.Amount        `json:"refund_pending_amount"`
+	Refunds             []model.RefundEntry `json:"refunds"`
+}
+
+type OrderListItem struct {
+	model.Order
+	Customer *model.Customer `json:"customer,omitempty"`
+}
+
+// OrderListResponse is one page of GET /api/orders. NextCursor is empty on the last page.
+type OrderListResponse struct {
+	Orders     []OrderListItem `json:"orders"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,26 @@
+package dto
+
+import "github.com/aakritigkmit/payment-gateway/internal/model"
+
+// CreateWebhookEndpointRequest registers a merchant endpoint. A signing secret is
+// generated when none is supplied; it is only returned in the create response.
+type CreateWebhookEndpointRequest struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description string   `json:"description,omitempty"`
+	Secret      string   `json:"secret,omitempty"`
+}
+
+// UpdateWebhookEndpointRequest changes the fields that are present in the body.
+type UpdateWebhookEndpointRequest struct {
+	URL         *string  `json:"url,omitempty"`
+	Events      []string `json:"events,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Active      *bool    `json:"active,omitempty"`
+}
+
+// WebhookDeliveryListResponse is one page of GET /api/webhooks/deliveries.
+type WebhookDeliveryListResponse struct {
+	Deliveries []model.WebhookDelivery `json:"deliveries"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aakritigkmit/payment-gateway/internal/repository"
+	"github.com/aakritigkmit/payment-gateway/internal/services"
+	"github.com/aakritigkmit/payment-gateway/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type JobHandler struct {
+	service *services.JobService
+}
+
+func NewJobHandler(service *services.JobService) *JobHandler {
+	return &JobHandler{service}
+}
+
+func (h *JobHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.service.ListJobs(r.Context())
+	if err != nil {
+		utils.SendErrorResponse(w, jobErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Jobs fetched successfully", jobs)
+}
+
+func (h *JobHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit, err := parseLimitParam(query.Get("limit"))
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	runs, err := h.service.ListRuns(r.Context(), query.Get("job"), query.Get("status"), query.Get("cursor"), limit)
+	if err != nil {
+		utils.SendErrorResponse(w, jobErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Job runs fetched successfully", runs)
+}
+
+func (h *JobHandler) GetRun(w http.ResponseWriter, r *http.Request) {
+	run, err := h.service.GetRun(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		utils.SendErrorResponse(w, jobErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Job run fetched successfully", run)
+}
+
+func (h *JobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.service.GetJob(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		utils.SendErrorResponse(w, jobErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Job fetched successfully", job)
+}
+
+func (h *JobHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.service.CancelJob(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		utils.SendErrorResponse(w, jobErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusAccepted, "Job cancellation requested", job)
+}
+
+// jobErrorStatus maps job service errors onto HTTP status codes.
+func jobErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrJobRunNotFound), errors.Is(err, repository.ErrBackgroundJobNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrJobNotCancellable):
+		return http.StatusConflict
+	case errors.Is(err, repository.ErrInvalidCursor):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -3,20 +3,26 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/aakritigkmit/payment-gateway/internal/dto"
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"github.com/aakritigkmit/payment-gateway/internal/repository"
 	"github.com/aakritigkmit/payment-gateway/internal/services"
 	"github.com/aakritigkmit/payment-gateway/internal/utils"
+	"github.com/go-chi/chi/v5"
 )
 
 type OrderHandler struct {
-	service *services.OrderService
+	service  *services.OrderService
+	verifier *services.CallbackVerifier
 }
 
-func NewOrderHandler(service *services.OrderService) *OrderHandler {
-	return &OrderHandler{service}
+func NewOrderHandler(service *services.OrderService, verifier *services.CallbackVerifier) *OrderHandler {
+	return &OrderHandler{service, verifier}
 }
 
 func (h *OrderHandler) PlaceOrder(w http.ResponseWriter, r *http.Request) {
@@ -45,13 +51,18 @@ func (h *OrderHandler) HandleCallback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	orderID := r.FormValue("order_id")
+	if err := h.verifier.VerifyPineLabsCallback(r.Context(), r.PostForm, r.RemoteAddr); err != nil {
+		http.Error(w, "Callback verification failed", http.StatusUnauthorized)
+		return
+	}
+
+	orderID := r.PostForm.Get("order_id")
 	if orderID == "" {
 		http.Error(w, "Missing order_id", http.StatusBadRequest)
 		return
 	}
 
-	status := r.FormValue("status")
+	status := r.PostForm.Get("status")
 	if status == "" {
 		http.Error(w, "Missing status", http.StatusBadRequest)
 		return
@@ -72,10 +83,151 @@ func (h *OrderHandler) RefundOrder(w http.ResponseWriter, r *http.Request) {
 
 	refundResp, err := h.service.ProcessRefund(r.Context(), req)
 	if err != nil {
-		utils.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		utils.SendErrorResponse(w, orderErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Refund processed successfully", refundResp.Raw)
+}
+
+func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "id")
+
+	orderResp, err := h.service.GetOrderDetails(r.Context(), orderID)
+	if err != nil {
+		utils.SendErrorResponse(w, orderErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Order fetched successfully", orderResp)
+}
+
+func (h *OrderHandler) GetOrderRefunds(w http.ResponseWriter, r *http.Request) {
+	refundsResp, err := h.service.GetOrderRefunds(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		utils.SendErrorResponse(w, orderErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Order refunds fetched successfully", refundsResp)
+}
+
+func (h *OrderHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	params := dto.OrderSearchParams{
+		Status:     query.Get("status"),
+		CustomerID: query.Get("customer_id"),
+		Email:      query.Get("email"),
+		Mobile:     query.Get("mobile"),
+		Currency:   query.Get("currency"),
+		Cursor:     query.Get("cursor"),
+		Limit:      defaultPageSize,
+	}
+
+	if params.Status != "" {
+		status, err := model.ParseOrderStatus(params.Status)
+		if err != nil {
+			utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		params.Status = string(status)
+	}
+
+	var err error
+	if params.From, err = parseDateParam(query.Get("from")); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid from date")
+		return
+	}
+	if params.To, err = parseDateParam(query.Get("to")); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid to date")
+		return
+	}
+	if params.Limit, err = parseLimitParam(query.Get("limit")); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	orders, err := h.service.SearchOrders(r.Context(), params)
+	if err != nil {
+		utils.SendErrorResponse(w, orderErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Orders fetched successfully", orders)
+}
+
+func (h *OrderHandler) CaptureOrder(w http.ResponseWriter, r *http.Request) {
+	var req dto.CaptureRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	captureResp, err := h.service.CaptureOrder(r.Context(), chi.URLParam(r, "id"), req)
+	if err != nil {
+		utils.SendErrorResponse(w, orderErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Order captured successfully", captureResp.Raw)
+}
+
+func (h *OrderHandler) VoidOrder(w http.ResponseWriter, r *http.Request) {
+	voidResp, err := h.service.VoidOrder(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		utils.SendErrorResponse(w, orderErrorStatus(err), err.Error())
 		return
 	}
 
-	utils.SendSuccessResponse(w, http.StatusOK, "Refund processed successfully", refundResp)
+	utils.SendSuccessResponse(w, http.StatusOK, "Order voided successfully", voidResp.Raw)
 }
 
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	cancelResp, err := h.service.CancelOrder(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		utils.SendErrorResponse(w, orderErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Order cancelled successfully", cancelResp.Raw)
+}
+
+// FailRefund fails a requested refund that the provider has no record of and releases
+// its amount. Support only.
+func (h *OrderHandler) FailRefund(w http.ResponseWriter, r *http.Request) {
+	var req dto.FailRefundRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	entry, err := h.service.FailRefund(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "refundId"), req.Reason)
+	if err != nil {
+		utils.SendErrorResponse(w, orderErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Refund failed successfully", entry)
+}
+
+// orderErrorStatus maps order service errors onto HTTP status codes.
+func orderErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrOrderNotFound), errors.Is(err, repository.ErrRefundEntryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrSupportOnly):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrInvalidOrderState), errors.Is(err, repository.ErrInvalidStatusTransition),
+		errors.Is(err, repository.ErrRefundBalanceExceeded), errors.Is(err, repository.ErrDuplicateRefundReference):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidAmount), errors.Is(err, repository.ErrInvalidCursor):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -3,26 +3,33 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/aakritigkmit/payment-gateway/internal/dto"
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"github.com/aakritigkmit/payment-gateway/internal/repository"
 	"github.com/aakritigkmit/payment-gateway/internal/services"
 	"github.com/aakritigkmit/payment-gateway/internal/utils"
+	"github.com/go-chi/chi/v5"
 )
 
 type ProductHandler struct {
-	service *services.ProductService
+	service  *services.ProductService
+	jobs     *services.JobRegistry
+	verifier *services.CallbackVerifier
 }
 
-func NewProductHandler(service *services.ProductService) *ProductHandler {
-	return &ProductHandler{service}
+func NewProductHandler(service *services.ProductService, jobs *services.JobRegistry, verifier *services.CallbackVerifier) *ProductHandler {
+	return &ProductHandler{service, jobs, verifier}
 }
 
 func (h *ProductHandler) SyncProducts(w http.ResponseWriter, r *http.Request) {
 
-	var req dto.ProductSyncRequest
+	var req dto.SyncProductsRequest
 	if r.Body != nil {
 		defer r.Body.Close()
 		err := json.NewDecoder(r.Body).Decode(&req)
@@ -32,19 +39,117 @@ func (h *ProductHandler) SyncProducts(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	profiles, err := h.service.ResolveSyncProfiles(r.Context(), req.ProfileIDs)
+	if err != nil {
+		utils.SendErrorResponse(w, syncProfileErrorStatus(err), err.Error())
+		return
+	}
+	if len(profiles) == 0 {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "No enabled sync profiles to run")
+		return
+	}
+
+	profileIDs := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		profileIDs = append(profileIDs, profile.ID.Hex())
+	}
+
 	// Launch background job
-	go func() {
-		bgCtx := context.Background()
+	job, err := h.jobs.Launch(model.BackgroundJobProductSync, map[string]interface{}{"profile_ids": profileIDs},
+		func(ctx context.Context, tracker *services.JobTracker) error {
+			return h.service.SyncProducts(ctx, profiles, tracker)
+		})
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
-		err := h.service.SyncProducts(bgCtx)
-		if err != nil {
-			log.Printf("Background sync failed: %v", err)
-		} else {
-			log.Println("Background sync completed successfully.")
-		}
-	}()
+	utils.SendSuccessResponse(w, http.StatusAccepted, "Products are syncing in the background", job)
+}
+
+func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	params := dto.ProductSearchParams{
+		CountryISOCode: strings.ToUpper(query.Get("country_iso_code")),
+		Type:           query.Get("type"),
+		Currency:       strings.ToUpper(query.Get("currency")),
+		Query:          strings.TrimSpace(query.Get("q")),
+		Cursor:         query.Get("cursor"),
+	}
+
+	var err error
+	if params.OperatorID, err = parseIntParam("operator_id", query.Get("operator_id")); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if params.ServiceID, err = parseIntParam("service_id", query.Get("service_id")); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if params.SubServiceID, err = parseIntParam("subservice_id", query.Get("subservice_id")); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if params.IncludeInactive, err = parseBoolParam("include_inactive", query.Get("include_inactive")); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if params.MinPrice, err = parseFloatParam("min_price", query.Get("min_price")); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if params.MaxPrice, err = parseFloatParam("max_price", query.Get("max_price")); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Prices are only comparable within one currency.
+	if (params.MinPrice != nil || params.MaxPrice != nil) && params.Currency == "" {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "currency is required with min_price or max_price")
+		return
+	}
+	if params.MinPrice != nil && params.MaxPrice != nil && *params.MinPrice > *params.MaxPrice {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "min_price must not exceed max_price")
+		return
+	}
+	if params.Limit, err = parseLimitParam(query.Get("limit")); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	products, err := h.service.SearchProducts(r.Context(), params)
+	if err != nil {
+		utils.SendErrorResponse(w, productErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Products fetched successfully", products)
+}
+
+func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
+	product, err := h.service.GetProduct(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		utils.SendErrorResponse(w, productErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Product fetched successfully", product)
+}
+
+func (h *ProductHandler) GetProductPriceHistory(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimitParam(r.URL.Query().Get("limit"))
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	history, err := h.service.GetPriceHistory(r.Context(), chi.URLParam(r, "id"), limit)
+	if err != nil {
+		utils.SendErrorResponse(w, productErrorStatus(err), err.Error())
+		return
+	}
 
-	utils.SendSuccessResponse(w, http.StatusAccepted, "Products are syncing in the background", nil)
+	utils.SendSuccessResponse(w, http.StatusOK, "Price history fetched successfully", history)
 }
 
 func (h *ProductHandler) GenerateProductReport(w http.ResponseWriter, r *http.Request) {
@@ -57,16 +162,22 @@ func (h *ProductHandler) GenerateProductReport(w http.ResponseWriter, r *http.Re
 		}
 	}
 
-	go func() {
-		bgCtx := context.Background()
-		if err := h.service.GenerateProductFetchReport(bgCtx, req); err != nil {
-			log.Printf("Product report generation failed: %v", err)
-		} else {
-			log.Println("Product report generated successfully.")
-		}
-	}()
+	params := map[string]interface{}{
+		"service_id":       req.ServiceID,
+		"country_iso_code": req.CountryISOCode,
+		"type":             req.Type,
+		"operator_id":      req.OperatorID,
+	}
+	job, err := h.jobs.Launch(model.BackgroundJobProductReport, params,
+		func(ctx context.Context, tracker *services.JobTracker) error {
+			return h.service.GenerateProductFetchReport(ctx, req, tracker)
+		})
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
-	utils.SendSuccessResponse(w, http.StatusAccepted, "Product report is being generated in the background", nil)
+	utils.SendSuccessResponse(w, http.StatusAccepted, "Product report is being generated in the background", job)
 }
 
 func (h *ProductHandler) GenerateProductReportByIDs(w http.ResponseWriter, r *http.Request) {
@@ -76,16 +187,16 @@ func (h *ProductHandler) GenerateProductReportByIDs(w http.ResponseWriter, r *ht
 		return
 	}
 
-	go func() {
-		bgCtx := context.Background()
-		if err := h.service.GenerateProductReportByIDs(bgCtx, req.ProductIDs); err != nil {
-			log.Printf("[ReportByIDs] Report generation failed: %v", err)
-		} else {
-			log.Println("[ReportByIDs] Report generated successfully.")
-		}
-	}()
+	job, err := h.jobs.Launch(model.BackgroundJobProductReportByIDs, map[string]interface{}{"product_ids": req.ProductIDs},
+		func(ctx context.Context, tracker *services.JobTracker) error {
+			return h.service.GenerateProductReportByIDs(ctx, req.ProductIDs, tracker)
+		})
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
-	utils.SendSuccessResponse(w, http.StatusAccepted, "Product report is being generated in the background", nil)
+	utils.SendSuccessResponse(w, http.StatusAccepted, "Product report is being generated in the background", job)
 }
 
 func (h *ProductHandler) HandleProductTransaction(w http.ResponseWriter, r *http.Request) {
@@ -98,13 +209,41 @@ func (h *ProductHandler) HandleProductTransaction(w http.ResponseWriter, r *http
 		return
 	}
 
-	if err := h.service.CreateAndSaveTransaction(ctx, req); err != nil {
+	tx, err := h.service.CreateAndSaveTransaction(ctx, req)
+	if err != nil {
+		var invalid *services.ValidationError
+		if errors.As(err, &invalid) {
+			utils.SendValidationErrorResponse(w, "Transaction request is invalid", invalid.Fields)
+			return
+		}
 		log.Printf("Failed: %v", err)
-		utils.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		utils.SendErrorResponse(w, productErrorStatus(err), err.Error())
 		return
 	}
 
-	utils.SendSuccessResponse(w, http.StatusOK, "Transaction created and saved successfully", nil)
+	utils.SendSuccessResponse(w, http.StatusOK, "Transaction created and saved successfully", tx)
+}
+
+func (h *ProductHandler) ConfirmProductTransaction(w http.ResponseWriter, r *http.Request) {
+	tx, err := h.service.ConfirmTransaction(r.Context(), chi.URLParam(r, "externalId"))
+	if err != nil {
+		log.Printf("Confirm failed: %v", err)
+		utils.SendErrorResponse(w, productErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Transaction confirmed", tx)
+}
+
+func (h *ProductHandler) CancelProductTransaction(w http.ResponseWriter, r *http.Request) {
+	tx, err := h.service.CancelTransaction(r.Context(), chi.URLParam(r, "externalId"))
+	if err != nil {
+		log.Printf("Cancel failed: %v", err)
+		utils.SendErrorResponse(w, productErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Transaction cancelled", tx)
 }
 
 // func (h *ProductHandler) CreateBulkProductTransaction(w http.ResponseWriter, r *http.Request) {
@@ -138,6 +277,11 @@ func (h *ProductHandler) CreateBulkProductTransaction(w http.ResponseWriter, r *
 	// Generate a new orderId and save it to DB
 	orderId, err := h.service.InitBulkProductTransaction(r.Context(), req)
 	if err != nil {
+		var invalid *services.ValidationError
+		if errors.As(err, &invalid) {
+			utils.SendValidationErrorResponse(w, "Bulk order is invalid", invalid.Fields)
+			return
+		}
 		utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to create transaction entry")
 		return
 	}
@@ -155,3 +299,53 @@ func (h *ProductHandler) CreateBulkProductTransaction(w http.ResponseWriter, r *
 		}
 	}()
 }
+
+func (h *ProductHandler) GetBulkOrderStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.service.GetBulkOrderStatus(r.Context(), chi.URLParam(r, "orderId"))
+	if err != nil {
+		utils.SendErrorResponse(w, productErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Order status fetched successfully", status)
+}
+
+// HandleDTOneCallback receives DT One transaction status callbacks. Any non-2xx reply
+// makes DT One retry, so callbacks for transactions not stored yet are answered 404.
+func (h *ProductHandler) HandleDTOneCallback(w http.ResponseWriter, r *http.Request) {
+	username, password, ok := r.BasicAuth()
+	if err := h.verifier.VerifyDTOneCallback(r.Context(), username, password, ok, r.RemoteAddr); err != nil {
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Callback verification failed")
+		return
+	}
+
+	var tx model.ProductTransaction
+	if err := json.NewDecoder(r.Body).Decode(&tx); err != nil || tx.ExternalID == "" {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid callback body")
+		return
+	}
+
+	if err := h.service.HandleTransactionCallback(r.Context(), tx); err != nil {
+		log.Printf("DT One callback for %s failed: %v", tx.ExternalID, err)
+		utils.SendErrorResponse(w, productErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Callback processed", nil)
+}
+
+// productErrorStatus maps product service errors onto HTTP status codes.
+func productErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrProductNotFound),
+		errors.Is(err, repository.ErrProductTransactionNotFound),
+		errors.Is(err, repository.ErrProductOrderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidTransactionState):
+		return http.StatusConflict
+	case errors.Is(err, repository.ErrInvalidCursor):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
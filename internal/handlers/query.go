@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// parseDateParam accepts an RFC 3339 timestamp or a YYYY-MM-DD date. Empty means no bound.
+func parseDateParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// parseLimitParam returns the requested page size, defaulting to and capped by the package limits.
+func parseLimitParam(value string) (int64, error) {
+	if value == "" {
+		return defaultPageSize, nil
+	}
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit < 1 {
+		return 0, fmt.Errorf("limit must be a positive integer")
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	return limit, nil
+}
+
+// parseIntParam parses an optional integer filter. Empty means zero, i.e. no filter.
+func parseIntParam(name, value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", name)
+	}
+	return n, nil
+}
+
+// parseFloatParam parses an optional decimal bound. Empty means no bound.
+func parseFloatParam(name, value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative number", name)
+	}
+	return &f, nil
+}
+
+// parseBoolParam parses an optional flag. Empty means false.
+func parseBoolParam(name, value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", name)
+	}
+	return b, nil
+}
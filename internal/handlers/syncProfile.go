@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/aakritigkmit/payment-gateway/internal/dto"
+	"github.com/aakritigkmit/payment-gateway/internal/repository"
+	"github.com/aakritigkmit/payment-gateway/internal/services"
+	"github.com/aakritigkmit/payment-gateway/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *ProductHandler) CreateSyncProfile(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateSyncProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	profile, err := h.service.CreateSyncProfile(r.Context(), req)
+	if err != nil {
+		utils.SendErrorResponse(w, syncProfileErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusCreated, "Sync profile created successfully", profile)
+}
+
+func (h *ProductHandler) ListSyncProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.service.ListSyncProfiles(r.Context())
+	if err != nil {
+		utils.SendErrorResponse(w, syncProfileErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Sync profiles fetched successfully", profiles)
+}
+
+func (h *ProductHandler) GetSyncProfile(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.service.GetSyncProfile(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		utils.SendErrorResponse(w, syncProfileErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Sync profile fetched successfully", profile)
+}
+
+func (h *ProductHandler) UpdateSyncProfile(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateSyncProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	profile, err := h.service.UpdateSyncProfile(r.Context(), chi.URLParam(r, "id"), req)
+	if err != nil {
+		utils.SendErrorResponse(w, syncProfileErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Sync profile updated successfully", profile)
+}
+
+func (h *ProductHandler) DeleteSyncProfile(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteSyncProfile(r.Context(), chi.URLParam(r, "id")); err != nil {
+		utils.SendErrorResponse(w, syncProfileErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Sync profile deleted successfully", nil)
+}
+
+// syncProfileErrorStatus maps sync profile errors onto HTTP status codes.
+func syncProfileErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrSyncProfileNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrDuplicateSyncProfile):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidSyncProfile):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/aakritigkmit/payment-gateway/internal/dto"
+	"github.com/aakritigkmit/payment-gateway/internal/repository"
+	"github.com/aakritigkmit/payment-gateway/internal/services"
+	"github.com/aakritigkmit/payment-gateway/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type WebhookHandler struct {
+	service *services.WebhookService
+}
+
+func NewWebhookHandler(service *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service}
+}
+
+func (h *WebhookHandler) CreateEndpoint(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateWebhookEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	endpoint, err := h.service.CreateEndpoint(r.Context(), req)
+	if err != nil {
+		utils.SendErrorResponse(w, webhookErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusCreated, "Webhook endpoint created successfully", endpoint)
+}
+
+func (h *WebhookHandler) ListEndpoints(w http.ResponseWriter, r *http.Request) {
+	endpoints, err := h.service.ListEndpoints(r.Context())
+	if err != nil {
+		utils.SendErrorResponse(w, webhookErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Webhook endpoints fetched successfully", endpoints)
+}
+
+func (h *WebhookHandler) GetEndpoint(w http.ResponseWriter, r *http.Request) {
+	endpoint, err := h.service.GetEndpoint(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		utils.SendErrorResponse(w, webhookErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Webhook endpoint fetched successfully", endpoint)
+}
+
+func (h *WebhookHandler) UpdateEndpoint(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateWebhookEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	endpoint, err := h.service.UpdateEndpoint(r.Context(), chi.URLParam(r, "id"), req)
+	if err != nil {
+		utils.SendErrorResponse(w, webhookErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Webhook endpoint updated successfully", endpoint)
+}
+
+func (h *WebhookHandler) DeleteEndpoint(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteEndpoint(r.Context(), chi.URLParam(r, "id")); err != nil {
+		utils.SendErrorResponse(w, webhookErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Webhook endpoint deleted successfully", nil)
+}
+
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit, err := parseLimitParam(query.Get("limit"))
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deliveries, err := h.service.ListDeliveries(r.Context(),
+		query.Get("endpoint_id"), query.Get("status"), query.Get("event_type"), query.Get("cursor"), limit)
+	if err != nil {
+		utils.SendErrorResponse(w, webhookErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Webhook deliveries fetched successfully", deliveries)
+}
+
+func (h *WebhookHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.service.GetDelivery(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		utils.SendErrorResponse(w, webhookErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusOK, "Webhook delivery fetched successfully", delivery)
+}
+
+func (h *WebhookHandler) ReplayDelivery(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.service.ReplayDelivery(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		utils.SendErrorResponse(w, webhookErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(w, http.StatusAccepted, "Webhook delivery queued for replay", delivery)
+}
+
+// webhookErrorStatus maps webhook service errors onto HTTP status codes.
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrWebhookEndpointNotFound), errors.Is(err, repository.ErrWebhookDeliveryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidWebhookRequest):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
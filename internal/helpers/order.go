@@ -3,26 +3,47 @@ package helpers
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/aakritigkmit/payment-gateway/internal/dto"
 	"github.com/aakritigkmit/payment-gateway/internal/model"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// pineOrderStatuses maps Pine Labs order statuses onto the gateway state machine.
+var pineOrderStatuses = map[string]model.OrderStatus{
+	"CREATED":            model.OrderStatusCreated,
+	"PENDING":            model.OrderStatusPending,
+	"ATTEMPTED":          model.OrderStatusPending,
+	"AUTHORIZED":         model.OrderStatusAuthorized,
+	"PROCESSED":          model.OrderStatusProcessed,
+	"FAILED":             model.OrderStatusFailed,
+	"CANCELLED":          model.OrderStatusCancelled,
+	"PARTIALLY_REFUNDED": model.OrderStatusPartiallyRefunded,
+	"REFUNDED":           model.OrderStatusRefunded,
+}
+
+// MapPineStatusToOrderStatus translates a status returned by GetOrderDetails.
+func MapPineStatusToOrderStatus(status string) (model.OrderStatus, bool) {
+	orderStatus, ok := pineOrderStatuses[strings.ToUpper(status)]
+	return orderStatus, ok
+}
+
 // StructToMap converts a struct to a map[string]interface{}
 // Returns error if marshalling/unmarshalling fails
 func BuildOrderPayload(req dto.PlaceOrderRequest) ([]byte, error) {
+	if err := req.OrderAmount.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid order amount: %w", err)
+	}
+
 	payload := map[string]interface{}{
 		"merchant_order_reference": req.MerchantOrderReference,
-		"order_amount": map[string]interface{}{
-			"value":    req.OrderAmount.Value,
-			"currency": req.OrderAmount.Currency,
-		},
-		"pre_auth":                req.PreAuth,
-		"allowed_payment_methods": req.AllowedPaymentMethods,
-		"notes":                   req.Notes,
-		"callback_url":            req.CallbackURL,
-		"failure_callback_url":    req.FailureCallbackURL,
+		"order_amount":             req.OrderAmount,
+		"pre_auth":                 req.PreAuth,
+		"allowed_payment_methods":  req.AllowedPaymentMethods,
+		"notes":                    req.Notes,
+		"callback_url":             req.CallbackURL,
+		"failure_callback_url":     req.FailureCallbackURL,
 		"purchase_details": map[string]interface{}{
 			"customer": map[string]interface{}{
 				"email_id":      req.PurchaseDetails.Customer.EmailID,
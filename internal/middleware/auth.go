@@ -29,6 +29,18 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		claims, _ := token.Claims.(jwt.MapClaims)
+		userID, _ := claims["user_id"].(string)
+		if userID == "" {
+			utils.SendErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+			return
+		}
+
+		ctx := utils.WithUserID(r.Context(), userID)
+		if role, ok := claims["role"].(string); ok {
+			ctx = utils.WithUserRole(ctx, role)
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -0,0 +1,136 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"github.com/aakritigkmit/payment-gateway/internal/repository"
+	"github.com/aakritigkmit/payment-gateway/internal/utils"
+)
+
+const (
+	idempotencyHeader    = "Idempotency-Key"
+	idempotencyLockTTL   = time.Minute
+	maxIdempotencyKeyLen = 255
+)
+
+// Idempotency replays the stored response for a repeated Idempotency-Key and
+// rejects a key reused with a different request body. Keys are scoped to the
+// authenticated caller, so it must run after AuthMiddleware. Requests without
+// the header pass straight through.
+//
+// Every response is stored, server errors included: a 5xx may come from a
+// provider call whose outcome is unknown, and running the request again could
+// place a second order or refund. The scoped key is passed to the handler in
+// the request context so services can derive stable provider references from it.
+func Idempotency(repo *repository.IdempotencyRepo) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotencyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLen {
+				utils.SendErrorResponse(w, http.StatusBadRequest, "Idempotency-Key is too long")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				utils.SendErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := r.Context()
+			scopedKey := utils.UserIDFromContext(ctx) + " " + r.Method + " " + r.URL.Path + " " + key
+			sum := sha256.Sum256(body)
+			requestHash := hex.EncodeToString(sum[:])
+
+			if replayed := replayIdempotentResponse(ctx, w, repo, scopedKey, requestHash); replayed {
+				return
+			}
+
+			lockKey := "idempotency:lock:" + scopedKey
+			lockToken, locked, err := utils.AcquireRedisLock(ctx, lockKey, idempotencyLockTTL)
+			if err != nil {
+				utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to acquire idempotency lock")
+				return
+			}
+			if !locked {
+				utils.SendErrorResponse(w, http.StatusConflict, "A request with this Idempotency-Key is already in progress")
+				return
+			}
+			// Release by token: after the TTL a later request may hold the lock.
+			defer utils.ReleaseRedisLock(context.Background(), lockKey, lockToken)
+
+			// The first request may have finished between the lookup and taking the lock.
+			if replayed := replayIdempotentResponse(ctx, w, repo, scopedKey, requestHash); replayed {
+				return
+			}
+
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r.WithContext(utils.WithIdempotencyKey(ctx, scopedKey)))
+
+			record := model.IdempotencyRecord{
+				Key:         scopedKey,
+				RequestHash: requestHash,
+				StatusCode:  rec.status,
+				Body:        rec.body.Bytes(),
+				CreatedAt:   time.Now(),
+			}
+			if err := repo.SaveRecord(context.Background(), record); err != nil {
+				log.Printf("[Idempotency] Failed to store response for key %s: %v", key, err)
+			}
+		})
+	}
+}
+
+// replayIdempotentResponse writes the stored response (or a 409 on a body
+// mismatch) and reports whether the request has been answered.
+func replayIdempotentResponse(ctx context.Context, w http.ResponseWriter, repo *repository.IdempotencyRepo, key, requestHash string) bool {
+	record, err := repo.FindByKey(ctx, key)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to look up Idempotency-Key")
+		return true
+	}
+	if record == nil {
+		return false
+	}
+
+	if record.RequestHash != requestHash {
+		utils.SendErrorResponse(w, http.StatusConflict, "Idempotency-Key was already used with a different request body")
+		return true
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(record.StatusCode)
+	w.Write(record.Body)
+	return true
+}
+
+// responseRecorder passes the response through while keeping a copy for storage.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,134 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// amountPaths lists, per collection, the fields that held floating point amounts.
+// Amounts were already expressed in Pine Labs minor units, so values are rounded
+// rather than rescaled. Paths descend into arrays automatically.
+var amountPaths = map[string][]string{
+	"orders": {"amount", "capturedAmount", "refundedAmount", "refundPendingAmount"},
+	"transactions": {
+		"orderamount.value",
+		"payments.payment_amount.value",
+		"refunds.orderamount.value",
+		"refunds.payments.payment_amount.value",
+	},
+	"refund": {
+		"orderamount.value",
+		"payments.payment_amount.value",
+		"refunds.orderamount.value",
+		"refunds.payments.payment_amount.value",
+	},
+	"refundCreateRespo": {"data.payments.payment_amount.value"},
+	"refund_ledger":     {"amount"},
+}
+
+func convertAmountsToMinorUnits(ctx context.Context, db *mongo.Database) error {
+	for collection, paths := range amountPaths {
+		converted, err := convertCollectionAmounts(ctx, db.Collection(collection), paths)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s: %w", collection, err)
+		}
+		log.Printf("[Migrations] Converted amounts in %d %s documents", converted, collection)
+	}
+	return nil
+}
+
+func convertCollectionAmounts(ctx context.Context, coll *mongo.Collection, paths []string) (int, error) {
+	filters := make(bson.A, 0, len(paths))
+	for _, path := range paths {
+		filters = append(filters, bson.M{path: bson.M{"$type": "double"}})
+	}
+
+	cursor, err := coll.Find(ctx, bson.M{"$or": filters})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	converted := 0
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return converted, err
+		}
+
+		set := bson.M{}
+		for _, path := range paths {
+			segments := strings.Split(path, ".")
+			value, changed := convertDoubles(doc[segments[0]], segments[1:])
+			if changed {
+				doc[segments[0]] = value
+				set[segments[0]] = value
+			}
+		}
+		if len(set) == 0 {
+			continue
+		}
+
+		if _, err := coll.UpdateOne(ctx, bson.M{"_id": doc["_id"]}, bson.M{"$set": set}); err != nil {
+			return converted, err
+		}
+		converted++
+	}
+	return converted, cursor.Err()
+}
+
+// convertDoubles rounds the double found at path below value to an int64,
+// visiting every element of any array along the way.
+func convertDoubles(value interface{}, path []string) (interface{}, bool) {
+	switch v := value.(type) {
+	case primitive.A:
+		changed := false
+		for i, elem := range v {
+			if next, ok := convertDoubles(elem, path); ok {
+				v[i] = next
+				changed = true
+			}
+		}
+		return v, changed
+	case primitive.M:
+		if len(path) == 0 {
+			return v, false
+		}
+		child, ok := v[path[0]]
+		if !ok {
+			return v, false
+		}
+		next, changed := convertDoubles(child, path[1:])
+		if changed {
+			v[path[0]] = next
+		}
+		return v, changed
+	case primitive.D:
+		if len(path) == 0 {
+			return v, false
+		}
+		changed := false
+		for i, elem := range v {
+			if elem.Key != path[0] {
+				continue
+			}
+			if next, ok := convertDoubles(elem.Value, path[1:]); ok {
+				v[i].Value = next
+				changed = true
+			}
+		}
+		return v, changed
+	case float64:
+		if len(path) == 0 {
+			return int64(math.Round(v)), true
+		}
+	}
+	return value, false
+}
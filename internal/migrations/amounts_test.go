@@ -0,0 +1,113 @@
+package migrations
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// decode round-trips doc through BSON so it has the types the migration sees.
+func decode(t *testing.T, doc bson.M) bson.M {
+	t.Helper()
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var out bson.M
+	if err := bson.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	return out
+}
+
+func TestConvertDoubles(t *testing.T) {
+	tests := []struct {
+		name        string
+		doc         bson.M
+		path        string
+		want        interface{}
+		wantChanged bool
+	}{
+		{
+			name:        "top-level double",
+			doc:         bson.M{"amount": 1250.0},
+			path:        "amount",
+			want:        int64(1250),
+			wantChanged: true,
+		},
+		{
+			name:        "rounds to the nearest minor unit",
+			doc:         bson.M{"amount": 1249.5},
+			path:        "amount",
+			want:        int64(1250),
+			wantChanged: true,
+		},
+		{
+			name:        "already an integer",
+			doc:         bson.M{"amount": int64(1250)},
+			path:        "amount",
+			want:        int64(1250),
+			wantChanged: false,
+		},
+		{
+			name:        "nested document",
+			doc:         bson.M{"orderamount": bson.M{"value": 99.9, "currency": "INR"}},
+			path:        "orderamount.value",
+			want:        primitive.M{"value": int64(100), "currency": "INR"},
+			wantChanged: true,
+		},
+		{
+			name: "array of documents",
+			doc: bson.M{"payments": bson.A{
+				bson.M{"payment_amount": bson.M{"value": 500.0}},
+				bson.M{"payment_amount": bson.M{"value": int64(700)}},
+			}},
+			path: "payments.payment_amount.value",
+			want: primitive.A{
+				primitive.M{"payment_amount": primitive.M{"value": int64(500)}},
+				primitive.M{"payment_amount": primitive.M{"value": int64(700)}},
+			},
+			wantChanged: true,
+		},
+		{
+			name:        "missing field",
+			doc:         bson.M{"orderamount": bson.M{"currency": "INR"}},
+			path:        "orderamount.value",
+			want:        primitive.M{"currency": "INR"},
+			wantChanged: false,
+		},
+		{
+			name:        "path stops at a document",
+			doc:         bson.M{"orderamount": bson.M{"value": 1.0}},
+			path:        "orderamount",
+			want:        primitive.M{"value": 1.0},
+			wantChanged: false,
+		},
+	}
+	for _, tt := range tests {
+		doc := decode(t, tt.doc)
+		segments := strings.Split(tt.path, ".")
+		got, changed := convertDoubles(doc[segments[0]], segments[1:])
+		if changed != tt.wantChanged {
+			t.Errorf("%s: changed = %v, want %v", tt.name, changed, tt.wantChanged)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %#v, want %#v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestConvertDoublesInOrderedDocument(t *testing.T) {
+	doc := primitive.D{{Key: "value", Value: 12.4}, {Key: "currency", Value: "INR"}}
+	got, changed := convertDoubles(doc, []string{"value"})
+	if !changed {
+		t.Fatal("changed = false, want true")
+	}
+	want := primitive.D{{Key: "value", Value: int64(12)}, {Key: "currency", Value: "INR"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+// Package migrations holds one-off data migrations. Each migration runs once per
+// database; applied IDs are recorded in the schema_migrations collection.
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Migration is a named, idempotent change to stored documents.
+type Migration struct {
+	ID          string
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// All lists the migrations in the order they must be applied.
+var All = []Migration{
+	{
+		ID:          "0001_amounts_to_minor_units",
+		Description: "store order, transaction and refund amounts as int64 minor units",
+		Up:          convertAmountsToMinorUnits,
+	},
+	{
+		ID:          "0002_unique_product_ids",
+		Description: "remove duplicate products and drop the non-unique unique_id index",
+		Up:          dedupeProducts,
+	},
+	{
+		ID:          "0003_default_sync_profiles",
+		Description: "seed sync profiles for the previously hardcoded gift card operators",
+		Up:          seedDefaultSyncProfiles,
+	},
+}
+
+// Run applies every migration in All that has not been recorded yet.
+func Run(ctx context.Context, db *mongo.Database) error {
+	applied := db.Collection("schema_migrations")
+
+	for _, m := range All {
+		err := applied.FindOne(ctx, bson.M{"_id": m.ID}).Err()
+		if err == nil {
+			log.Printf("[Migrations] %s already applied", m.ID)
+			continue
+		}
+		if err != mongo.ErrNoDocuments {
+			return fmt.Errorf("failed to check migration %s: %w", m.ID, err)
+		}
+
+		log.Printf("[Migrations] Applying %s: %s", m.ID, m.Description)
+		if err := m.Up(ctx, db); err != nil {
+			return fmt.Errorf("migration %s failed: %w", m.ID, err)
+		}
+
+		if _, err := applied.InsertOne(ctx, bson.M{"_id": m.ID, "appliedAt": time.Now()}); err != nil {
+			return fmt.Errorf("failed to record migration %s: %w", m.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// dedupeProducts removes products stored more than once for the same DT One ID,
+// keeping the most recently synced copy, and drops the old non-unique unique_id
+// index so ProductRepo.EnsureIndexes can create the unique one.
+func dedupeProducts(ctx context.Context, db *mongo.Database) error {
+	products := db.Collection("products")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$sort", Value: bson.D{{Key: "synced_at", Value: -1}, {Key: "_id", Value: -1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$unique_id",
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	}
+	cursor, err := products.Aggregate(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("failed to find duplicate products: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	removed := int64(0)
+	for cursor.Next(ctx) {
+		var group struct {
+			UniqueID interface{}   `bson:"_id"`
+			IDs      []interface{} `bson:"ids"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return fmt.Errorf("failed to decode duplicate products: %w", err)
+		}
+		res, err := products.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": group.IDs[1:]}})
+		if err != nil {
+			return fmt.Errorf("failed to remove duplicates of product %v: %w", group.UniqueID, err)
+		}
+		removed += res.DeletedCount
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to read duplicate products: %w", err)
+	}
+	log.Printf("[Migrations] Removed %d duplicate products", removed)
+
+	if _, err := products.Indexes().DropOne(ctx, "unique_id_1"); err != nil {
+		// NamespaceNotFound (26) or IndexNotFound (27): there is nothing to drop.
+		var cmdErr mongo.CommandError
+		if !errors.As(err, &cmdErr) || (cmdErr.Code != 26 && cmdErr.Code != 27) {
+			return fmt.Errorf("failed to drop product unique_id index: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/constants"
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// seedDefaultSyncProfiles creates profiles for the gift card operators product sync
+// used to hardcode, so an upgraded deployment keeps syncing the same catalog. It runs
+// once: profiles deleted afterwards stay deleted. A database that already has profiles
+// is left as it is.
+func seedDefaultSyncProfiles(ctx context.Context, db *mongo.Database) error {
+	profiles := db.Collection("sync_profiles")
+
+	count, err := profiles.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("failed to count sync profiles: %w", err)
+	}
+	if count > 0 {
+		log.Printf("[Migrations] %d sync profiles exist, not seeding defaults", count)
+		return nil
+	}
+
+	now := time.Now()
+	for operator, operatorID := range constants.ProductOperatorId {
+		profile := model.SyncProfile{
+			Name:       operator,
+			ServiceID:  constants.ProductServiceIDs.GiftCards,
+			Type:       constants.ProductTypes.FixedValuePinPurchase,
+			OperatorID: operatorID,
+			Enabled:    true,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		// Upsert on the profile's filters so a rerun after a partial failure does
+		// not create duplicates.
+		filter := bson.M{
+			"service_id":       profile.ServiceID,
+			"type":             profile.Type,
+			"country_iso_code": profile.CountryISOCode,
+			"operator_id":      profile.OperatorID,
+		}
+		_, err := profiles.UpdateOne(ctx, filter, bson.M{"$setOnInsert": profile}, options.Update().SetUpsert(true))
+		if err != nil {
+			return fmt.Errorf("failed to seed sync profile %s: %w", operator, err)
+		}
+	}
+	log.Printf("[Migrations] Seeded %d default sync profiles", len(constants.ProductOperatorId))
+	return nil
+}
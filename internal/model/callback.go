@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CallbackRejection records an inbound provider callback that failed verification.
+type CallbackRejection struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Source     string             `bson:"source" json:"source"`
+	OrderID    string             `bson:"order_id" json:"order_id"`
+	Reason     string             `bson:"reason" json:"reason"`
+	RemoteAddr string             `bson:"remote_addr" json:"remote_addr"`
+	Fields     map[string]string  `bson:"fields" json:"fields"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IdempotencyRecord stores the response produced for an Idempotency-Key so retries can be replayed.
+type IdempotencyRecord struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Key         string             `bson:"key" json:"key"`
+	RequestHash string             `bson:"request_hash" json:"request_hash"`
+	StatusCode  int                `bson:"status_code" json:"status_code"`
+	Body        []byte             `bson:"body" json:"body"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+}
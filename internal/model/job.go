@@ -0,0 +1,100 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type JobRunStatus string
+
+const (
+	JobRunRunning   JobRunStatus = "running"
+	JobRunSucceeded JobRunStatus = "succeeded"
+	JobRunFailed    JobRunStatus = "failed"
+)
+
+// ScheduledJob is the shared view of a job registered with the scheduler. Every replica
+// registers the same jobs, so the document is keyed by job name.
+type ScheduledJob struct {
+	Name       string             `bson:"_id" json:"name"`
+	Schedule   string             `bson:"schedule" json:"schedule"`
+	NextRunAt  *time.Time         `bson:"next_run_at,omitempty" json:"next_run_at,omitempty"`
+	LastRunAt  *time.Time         `bson:"last_run_at,omitempty" json:"last_run_at,omitempty"`
+	LastRunID  primitive.ObjectID `bson:"last_run_id,omitempty" json:"last_run_id,omitempty"`
+	LastStatus JobRunStatus       `bson:"last_status,omitempty" json:"last_status,omitempty"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// JobRun is one execution of a scheduled job.
+type JobRun struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Job        string             `bson:"job" json:"job"`
+	Status     JobRunStatus       `bson:"status" json:"status"`
+	Instance   string             `bson:"instance" json:"instance"`
+	StartedAt  time.Time          `bson:"started_at" json:"started_at"`
+	FinishedAt *time.Time         `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+	DurationMs int64              `bson:"duration_ms,omitempty" json:"duration_ms,omitempty"`
+	Summary    string             `bson:"summary,omitempty" json:"summary,omitempty"`
+	Error      string             `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+type BackgroundJobType string
+
+const (
+	BackgroundJobProductSync        BackgroundJobType = "product_sync"
+	BackgroundJobProductReport      BackgroundJobType = "product_report"
+	BackgroundJobProductReportByIDs BackgroundJobType = "product_report_by_ids"
+)
+
+type BackgroundJobStatus string
+
+const (
+	BackgroundJobRunning   BackgroundJobStatus = "running"
+	BackgroundJobSucceeded BackgroundJobStatus = "succeeded"
+	BackgroundJobFailed    BackgroundJobStatus = "failed"
+	BackgroundJobCancelled BackgroundJobStatus = "cancelled"
+)
+
+// IsFinal reports whether the job has stopped running.
+func (s BackgroundJobStatus) IsFinal() bool {
+	return s != BackgroundJobRunning
+}
+
+// JobProgress counts the units of work a background job has done. What a unit is
+// depends on the job: products for a sync, pages for a DT One report.
+type JobProgress struct {
+	Total     int `bson:"total" json:"total"`
+	Processed int `bson:"processed" json:"processed"`
+	Succeeded int `bson:"succeeded" json:"succeeded"`
+	Failed    int `bson:"failed" json:"failed"`
+}
+
+type ProductSaveError struct {
+	ProductID int    `bson:"product_id" json:"product_id"`
+	ErrorMsg  string `bson:"error" json:"error"`
+}
+
+type FetchPageError struct {
+	Page     int    `bson:"page" json:"page"`
+	ErrorMsg string `bson:"error" json:"error"`
+}
+
+// BackgroundJob is a long-running task started from the API, such as a product sync.
+// Progress is flushed periodically while it runs; setting CancelRequested stops it at
+// the next flush on whichever replica runs it.
+type BackgroundJob struct {
+	ID              primitive.ObjectID     `bson:"_id,omitempty" json:"id,omitempty"`
+	Type            BackgroundJobType      `bson:"type" json:"type"`
+	Status          BackgroundJobStatus    `bson:"status" json:"status"`
+	Params          map[string]interface{} `bson:"params,omitempty" json:"params,omitempty"`
+	Progress        JobProgress            `bson:"progress" json:"progress"`
+	SaveErrors      []ProductSaveError     `bson:"save_errors" json:"save_errors"`
+	FetchErrors     []FetchPageError       `bson:"fetch_errors" json:"fetch_errors"`
+	Error           string                 `bson:"error,omitempty" json:"error,omitempty"`
+	CancelRequested bool                   `bson:"cancel_requested" json:"cancel_requested"`
+	Instance        string                 `bson:"instance" json:"instance"`
+	CreatedAt       time.Time              `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time              `bson:"updated_at" json:"updated_at"`
+	FinishedAt      *time.Time             `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
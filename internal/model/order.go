@@ -3,16 +3,25 @@ package model
 import (
 	"time"
 
+	"github.com/aakritigkmit/payment-gateway/internal/money"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Order struct {
-	ID                     primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	UserID                 string             `bson:"userId"`
-	TransactionReferenceId string             `bson:"transactionReferenceId"`
-	Amount                 float32            `bson:"amount"`
-	Currency               string             `bson:"currency"`
-	Status                 string             `bson:"status"`
-	CreatedAt              time.Time          `bson:"createdAt,omitempty" json:"createdAt,omitempty"`
-	UpdatedAt              time.Time          `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
+	ID                     primitive.ObjectID      `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID                 string                  `bson:"userId" json:"userId"`
+	CreatedBy              string                  `bson:"createdBy,omitempty" json:"createdBy,omitempty"`
+	TransactionReferenceId string                  `bson:"transactionReferenceId" json:"transactionReferenceId"`
+	Amount                 money.Amount            `bson:"amount" json:"amount"`
+	Currency               string                  `bson:"currency" json:"currency"`
+	CapturedAmount         money.Amount            `bson:"capturedAmount" json:"capturedAmount"`
+	RefundedAmount         money.Amount            `bson:"refundedAmount" json:"refundedAmount"`
+	RefundPendingAmount    money.Amount            `bson:"refundPendingAmount" json:"refundPendingAmount"`
+	Status                 OrderStatus             `bson:"status" json:"status"`
+	StatusHistory          []OrderStatusTransition `bson:"statusHistory,omitempty" json:"statusHistory,omitempty"`
+	LastReconciledAt       *time.Time              `bson:"lastReconciledAt,omitempty" json:"lastReconciledAt,omitempty"`
+	ExpiryAttempts         int                     `bson:"expiryAttempts,omitempty" json:"expiryAttempts,omitempty"`
+	ExpiryRetryAt          *time.Time              `bson:"expiryRetryAt,omitempty" json:"expiryRetryAt,omitempty"`
+	CreatedAt              time.Time               `bson:"createdAt,omitempty" json:"createdAt,omitempty"`
+	UpdatedAt              time.Time               `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
 }
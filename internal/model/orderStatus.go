@@ -0,0 +1,120 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OrderStatus is the gateway's lifecycle state for an order.
+type OrderStatus string
+
+const (
+	OrderStatusCreated           OrderStatus = "created"
+	OrderStatusPending           OrderStatus = "pending"
+	OrderStatusAuthorized        OrderStatus = "authorized"
+	OrderStatusProcessed         OrderStatus = "processed"
+	OrderStatusFailed            OrderStatus = "failed"
+	OrderStatusCancelled         OrderStatus = "cancelled"
+	OrderStatusExpired           OrderStatus = "expired"
+	OrderStatusPartiallyRefunded OrderStatus = "partially_refunded"
+	OrderStatusRefunded          OrderStatus = "refunded"
+)
+
+// Actors recorded on status transitions that are not caused by an API user.
+const (
+	ActorSystem           = "system"
+	ActorPineLabsCallback = "pinelabs_callback"
+	ActorReconciler       = "reconciler"
+	ActorExpiry           = "expiry"
+)
+
+// UserActor identifies an authenticated API user as the cause of a transition.
+func UserActor(userID string) string {
+	return "user:" + userID
+}
+
+// AwaitingPaymentStatuses are the states in which the payment outcome is not yet known.
+var AwaitingPaymentStatuses = []OrderStatus{OrderStatusCreated, OrderStatusPending, OrderStatusAuthorized}
+
+// UnpaidStatuses are the states in which the customer has not paid or authorized anything yet.
+var UnpaidStatuses = []OrderStatus{OrderStatusCreated, OrderStatusPending}
+
+// orderTransitions lists, for each state, the states it may move to.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusCreated:           {OrderStatusPending, OrderStatusAuthorized, OrderStatusProcessed, OrderStatusFailed, OrderStatusCancelled, OrderStatusExpired},
+	OrderStatusPending:           {OrderStatusAuthorized, OrderStatusProcessed, OrderStatusFailed, OrderStatusCancelled, OrderStatusExpired},
+	OrderStatusAuthorized:        {OrderStatusProcessed, OrderStatusFailed, OrderStatusCancelled},
+	OrderStatusProcessed:         {OrderStatusPartiallyRefunded, OrderStatusRefunded},
+	OrderStatusPartiallyRefunded: {OrderStatusRefunded},
+	OrderStatusFailed:            {},
+	OrderStatusCancelled:         {},
+	OrderStatusExpired:           {},
+	OrderStatusRefunded:          {},
+}
+
+// NormalizeOrderStatus maps stored values, including the legacy "Pending" and
+// "Partially Refunded" spellings, onto the canonical status.
+func NormalizeOrderStatus(raw string) OrderStatus {
+	return OrderStatus(strings.ReplaceAll(strings.ToLower(strings.TrimSpace(raw)), " ", "_"))
+}
+
+// StoredStatusValues returns the values a status may be stored as: the canonical
+// value plus the legacy Title Case spelling (e.g. "Partially Refunded").
+func StoredStatusValues(statuses ...OrderStatus) []string {
+	values := make([]string, 0, len(statuses)*2)
+	for _, status := range statuses {
+		words := strings.Split(string(status), "_")
+		for i, word := range words {
+			if word != "" {
+				words[i] = strings.ToUpper(word[:1]) + word[1:]
+			}
+		}
+		values = append(values, string(status), strings.Join(words, " "))
+	}
+	return values
+}
+
+// ParseOrderStatus validates a status supplied by a caller.
+func ParseOrderStatus(raw string) (OrderStatus, error) {
+	status := NormalizeOrderStatus(raw)
+	if _, ok := orderTransitions[status]; !ok {
+		return "", fmt.Errorf("unknown order status %q", raw)
+	}
+	return status, nil
+}
+
+// CanTransitionTo reports whether the state machine allows moving from s to next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[NormalizeOrderStatus(string(s))] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUnpaid reports whether s is one of UnpaidStatuses.
+func (s OrderStatus) IsUnpaid() bool {
+	status := NormalizeOrderStatus(string(s))
+	for _, unpaid := range UnpaidStatuses {
+		if status == unpaid {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether no further transitions are possible.
+func (s OrderStatus) IsTerminal() bool {
+	return len(orderTransitions[NormalizeOrderStatus(string(s))]) == 0
+}
+
+// OrderStatusTransition is one entry of an order's status history.
+type OrderStatusTransition struct {
+	From   OrderStatus `bson:"from,omitempty" json:"from,omitempty"`
+	To     OrderStatus `bson:"to" json:"to"`
+	Actor  string      `bson:"actor" json:"actor"`
+	Reason string      `bson:"reason,omitempty" json:"reason,omitempty"`
+	At     time.Time   `bson:"at" json:"at"`
+}
@@ -1,8 +1,10 @@
 package model
 
 import (
+	"strings"
 	"time"
 
+	"github.com/aakritigkmit/payment-gateway/internal/constants"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -13,6 +15,17 @@ type Amount struct {
 	TotalIncludingTax interface{} `bson:"total_including_tax" json:"total_including_tax"`
 }
 
+// ProductAmount is the value of a product on the source (sender) or destination
+// (beneficiary) side. Fixed-value products have Amount; ranged-value products accept
+// any amount from MinAmount to MaxAmount.
+type ProductAmount struct {
+	Amount    *float64 `bson:"amount,omitempty" json:"amount,omitempty"`
+	MinAmount *float64 `bson:"min_amount,omitempty" json:"min_amount,omitempty"`
+	MaxAmount *float64 `bson:"max_amount,omitempty" json:"max_amount,omitempty"`
+	Unit      string   `bson:"unit" json:"unit"`
+	UnitType  string   `bson:"unit_type" json:"unit_type"`
+}
+
 type Prices struct {
 	Retail    interface{} `bson:"retail" json:"retail"`
 	Wholesale Wholesale   `bson:"wholesale" json:"wholesale"`
@@ -66,6 +79,28 @@ type Status struct {
 	Message string      `bson:"message" json:"message"`
 }
 
+// Rank orders DT One statuses along a transaction's lifecycle, so a late or repeated
+// update never moves a transaction back. Final statuses share a rank; a reversal can
+// only follow completion.
+func (s Status) Rank() int {
+	switch s.Class.ID {
+	case constants.TransactionStatusClasses.Created:
+		return 1
+	case constants.TransactionStatusClasses.Confirmed:
+		return 2
+	case constants.TransactionStatusClasses.Submitted:
+		return 3
+	case constants.TransactionStatusClasses.Completed,
+		constants.TransactionStatusClasses.Rejected,
+		constants.TransactionStatusClasses.Cancelled,
+		constants.TransactionStatusClasses.Declined:
+		return 4
+	case constants.TransactionStatusClasses.Reversed:
+		return 5
+	}
+	return 0
+}
+
 type StatusClass struct {
 	ID      int    `bson:"id" json:"id"`
 	Message string `bson:"message" json:"message"`
@@ -76,7 +111,7 @@ type Product struct {
 	AvailabilityZones                   []string           `bson:"availability_zones" json:"availability_zones"`
 	Benefits                            []Benefit          `bson:"benefits" json:"benefits"`
 	Description                         string             `bson:"description" json:"description"`
-	Destination                         Amount             `bson:"destination" json:"destination"`
+	Destination                         ProductAmount      `bson:"destination" json:"destination"`
 	ID                                  primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
 	UniqueId                            int                `bson:"unique_id,omitempty" json:"id"`
 	Name                                string             `bson:"name" json:"name"`
@@ -86,16 +121,42 @@ type Product struct {
 	Rates                               Rates              `bson:"rates" json:"rates"`
 	Regions                             interface{}        `bson:"regions" json:"regions"`
 	RequiredAdditionalIdentifierFields  interface{}        `bson:"required_additional_identifier_fields" json:"required_additional_identifier_fields"`
-	RequiredBeneficiaryFields           interface{}        `bson:"required_beneficiary_fields" json:"required_beneficiary_fields"`
+	RequiredBeneficiaryFields           [][]string         `bson:"required_beneficiary_fields" json:"required_beneficiary_fields"`
 	RequiredCreditPartyIdentifierFields [][]string         `bson:"required_credit_party_identifier_fields" json:"required_credit_party_identifier_fields"`
-	RequiredDebitPartyIdentifierFields  interface{}        `bson:"required_debit_party_identifier_fields" json:"required_debit_party_identifier_fields"`
-	RequiredSenderFields                interface{}        `bson:"required_sender_fields" json:"required_sender_fields"`
-	RequiredStatementIdentifierFields   interface{}        `bson:"required_statement_identifier_fields" json:"required_statement_identifier_fields"`
+	RequiredDebitPartyIdentifierFields  [][]string         `bson:"required_debit_party_identifier_fields" json:"required_debit_party_identifier_fields"`
+	RequiredSenderFields                [][]string         `bson:"required_sender_fields" json:"required_sender_fields"`
+	RequiredStatementIdentifierFields   [][]string         `bson:"required_statement_identifier_fields" json:"required_statement_identifier_fields"`
 	Service                             Service            `bson:"service" json:"service"`
-	Source                              Amount             `bson:"source" json:"source"`
+	Source                              ProductAmount      `bson:"source" json:"source"`
 	Tags                                interface{}        `bson:"tags" json:"tags"`
 	Type                                string             `bson:"type" json:"type"`
 	Validity                            Validity           `bson:"validity" json:"validity"`
+
+	// Sync bookkeeping. ContentHash and PriceHash let the sync skip products DT One
+	// has not changed; Inactive is set when a product drops out of DT One's catalog.
+	ContentHash   string     `bson:"content_hash,omitempty" json:"-"`
+	PriceHash     string     `bson:"price_hash,omitempty" json:"-"`
+	SyncedAt      *time.Time `bson:"synced_at,omitempty" json:"synced_at,omitempty"`
+	Inactive      bool       `bson:"inactive,omitempty" json:"inactive"`
+	DeactivatedAt *time.Time `bson:"deactivated_at,omitempty" json:"deactivated_at,omitempty"`
+}
+
+// ProductPriceHistory records a change to a product's prices or rates seen during sync.
+// Old values are unset for the first sync of a product.
+type ProductPriceHistory struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	ProductID int                `bson:"product_id" json:"product_id"`
+	OldPrices *Prices            `bson:"old_prices,omitempty" json:"old_prices,omitempty"`
+	NewPrices Prices             `bson:"new_prices" json:"new_prices"`
+	OldRates  *Rates             `bson:"old_rates,omitempty" json:"old_rates,omitempty"`
+	NewRates  Rates              `bson:"new_rates" json:"new_rates"`
+	ChangedAt time.Time          `bson:"changed_at" json:"changed_at"`
+}
+
+// IsRanged reports whether the product is bought for a caller-chosen amount rather
+// than a fixed value.
+func (p Product) IsRanged() bool {
+	return strings.HasPrefix(p.Type, "RANGED_VALUE_")
 }
 
 // Benefit Model
@@ -124,10 +185,21 @@ type ProductTransaction struct {
 	Promotions                 interface{}           `bson:"promotions" json:"promotions"`
 	Rates                      Rates                 `bson:"rates" json:"rates"`
 	Status                     Status                `bson:"status" json:"status"`
+	StatusRank                 int                   `bson:"status_rank" json:"-"`
 	CreatedAt                  time.Time             `bson:"created_at" json:"created_at"`
 	UpdatedAt                  time.Time             `bson:"updated_at" json:"updated_at"`
 	UpdationTime               string                `bson:"updation_time" json:"updation_time"`
 	DeletedAt                  time.Time             `bson:"deleted_at" json:"deleted_at"`
+
+	// OrderID links transactions placed by a bulk order to its product_order, and
+	// LineItemIndex to the line item they fulfil.
+	OrderID       string `bson:"order_id,omitempty" json:"order_id,omitempty"`
+	LineItemIndex *int   `bson:"line_item_index,omitempty" json:"-"`
+
+	// CreatedBy is the user who created a single transaction; only they may confirm
+	// or cancel it. AutoConfirm is false for transactions held for confirmation.
+	CreatedBy   string `bson:"created_by,omitempty" json:"-"`
+	AutoConfirm *bool  `bson:"auto_confirm,omitempty" json:"auto_confirm,omitempty"`
 }
 
 type ProductPinItem struct {
@@ -139,12 +211,26 @@ type ProductPinItem struct {
 	} `bson:"pin"`
 }
 
+// ProductPin is a bulk product order (the product_order collection).
 type ProductPin struct {
 	OrderID     string           `bson:"orderID"`
 	ProductPins []ProductPinItem `bson:"productPins"`
-	CreatedAt   time.Time        `bson:"created_at" json:"created_at"`
-	UpdatedAt   time.Time        `bson:"updated_at" json:"updated_at"`
-	DeletedAt   time.Time        `bson:"deleted_at" json:"deleted_at"`
+	// UserID is the user who placed the order; only they are shown its pins.
+	UserID    string                 `bson:"userId,omitempty" json:"userId,omitempty"`
+	LineItems []ProductOrderLineItem `bson:"lineItems,omitempty" json:"lineItems,omitempty"`
+	// FinishedAt is set once bulk processing has stopped, whatever its outcome.
+	FinishedAt *time.Time `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `bson:"updated_at" json:"updated_at"`
+	DeletedAt  time.Time  `bson:"deleted_at" json:"deleted_at"`
+}
+
+// ProductOrderLineItem is one requested line of a bulk product order.
+type ProductOrderLineItem struct {
+	ProductID         int      `bson:"productId" json:"productId"`
+	Quantity          int      `bson:"quantity" json:"quantity"`
+	SourceAmount      *float64 `bson:"source_amount,omitempty" json:"source_amount,omitempty"`
+	DestinationAmount *float64 `bson:"destination_amount,omitempty" json:"destination_amount,omitempty"`
 }
 
 // CreditPartyIdentifier Model
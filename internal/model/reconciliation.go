@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReconciliationChange is the outcome for one order in a reconciliation run.
+type ReconciliationChange struct {
+	OrderID string      `bson:"order_id" json:"order_id"`
+	From    OrderStatus `bson:"from" json:"from"`
+	To      OrderStatus `bson:"to,omitempty" json:"to,omitempty"`
+	Error   string      `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+// ReconciliationRun summarises one pass of the stale order reconciler.
+type ReconciliationRun struct {
+	ID         primitive.ObjectID     `bson:"_id,omitempty" json:"id,omitempty"`
+	StartedAt  time.Time              `bson:"started_at" json:"started_at"`
+	FinishedAt time.Time              `bson:"finished_at" json:"finished_at"`
+	Cutoff     time.Time              `bson:"cutoff" json:"cutoff"`
+	Checked    int                    `bson:"checked" json:"checked"`
+	Updated    int                    `bson:"updated" json:"updated"`
+	Unchanged  int                    `bson:"unchanged" json:"unchanged"`
+	Failed     int                    `bson:"failed" json:"failed"`
+	Changes    []ReconciliationChange `bson:"changes" json:"changes"`
+	Errors     []ReconciliationChange `bson:"errors" json:"errors"`
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefundStatus is the lifecycle state of a single refund request.
+type RefundStatus string
+
+const (
+	RefundStatusRequested RefundStatus = "requested"
+	RefundStatusPending   RefundStatus = "pending"
+	RefundStatusSucceeded RefundStatus = "succeeded"
+	RefundStatusFailed    RefundStatus = "failed"
+)
+
+// IsTerminal reports whether the refund has reached its final status.
+func (s RefundStatus) IsTerminal() bool {
+	return s == RefundStatusSucceeded || s == RefundStatusFailed
+}
+
+// RefundEntry is one row of the refund ledger. Each refund request gets its own
+// entry; the amount is reserved against the order while the entry is open.
+// NeedsReview flags a requested refund the provider still does not list long after it
+// was submitted; its amount stays reserved until the provider lists it or an operator
+// fails it.
+type RefundEntry struct {
+	ID                      primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	OrderID                 string             `bson:"order_id" json:"order_id"`
+	MerchantRefundReference string             `bson:"merchant_refund_reference" json:"merchant_refund_reference"`
+	Amount                  money.Amount       `bson:"amount" json:"amount"`
+	Currency                string             `bson:"currency" json:"currency"`
+	Reason                  string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	Metadata                map[string]string  `bson:"metadata,omitempty" json:"metadata,omitempty"`
+	Status                  RefundStatus       `bson:"status" json:"status"`
+	ProviderRefundID        string             `bson:"provider_refund_id,omitempty" json:"provider_refund_id,omitempty"`
+	ProviderStatus          string             `bson:"provider_status,omitempty" json:"provider_status,omitempty"`
+	FailureReason           string             `bson:"failure_reason,omitempty" json:"failure_reason,omitempty"`
+	RequestedBy             string             `bson:"requested_by" json:"requested_by"`
+	LastCheckedAt           *time.Time         `bson:"last_checked_at,omitempty" json:"last_checked_at,omitempty"`
+	Checks                  int                `bson:"checks,omitempty" json:"checks,omitempty"`
+	NeedsReview             bool               `bson:"needs_review,omitempty" json:"needs_review,omitempty"`
+	CreatedAt               time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt               time.Time          `bson:"updated_at" json:"updated_at"`
+}
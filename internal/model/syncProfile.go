@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SyncProfile is one slice of the DT One catalog that product sync pulls. Zero-valued
+// filters are not sent to DT One, so a profile with only ServiceID syncs the whole service.
+type SyncProfile struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name           string             `bson:"name" json:"name"`
+	ServiceID      int                `bson:"service_id" json:"service_id"`
+	Type           string             `bson:"type" json:"type,omitempty"`
+	CountryISOCode string             `bson:"country_iso_code" json:"country_iso_code,omitempty"`
+	OperatorID     int                `bson:"operator_id" json:"operator_id,omitempty"`
+	Enabled        bool               `bson:"enabled" json:"enabled"`
+	LastSyncedAt   *time.Time         `bson:"last_synced_at,omitempty" json:"last_synced_at,omitempty"`
+	LastSyncCount  int                `bson:"last_sync_count" json:"last_sync_count"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+}
@@ -3,13 +3,12 @@ package model
 import (
 	"time"
 
+	"github.com/aakritigkmit/payment-gateway/internal/money"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-type OrderAmount struct {
-	Value    float32 `json:"value"`
-	Currency string  `json:"currency"`
-}
+// OrderAmount is stored as {value, currency} with value in minor units.
+type OrderAmount = money.Money
 
 type PurchaseDetail struct {
 	Customer         Customer         `json:"customer"`
@@ -34,6 +33,7 @@ type Transaction struct {
 	MerchantOrderReference string             `json:"merchant_order_reference"`
 	Type                   string             `json:"type"`
 	MerchantID             string             `json:"merchant_id"`
+	Provider               string             `json:"provider" bson:"provider"`
 	OrderAmount            OrderAmount        `json:"order_amount"`
 	PreAuth                bool               `json:"pre_auth"`
 	AllowedPaymentMethods  []string           `json:"allowed_payment_methods"`
@@ -101,7 +101,7 @@ type RefundOrderData struct {
 	Type                    string          `bson:"type" json:"type"`
 	Status                  string          `bson:"status" json:"status"`
 	MerchantID              string          `bson:"merchant_id" json:"merchant_id"`
-	OrderAmount             Amount          `bson:"order_amount" json:"order_amount"`
+	OrderAmount             OrderAmount     `bson:"order_amount" json:"order_amount"`
 	PurchaseDetails         PurchaseDetails `bson:"purchase_details" json:"purchase_details"`
 	Payments                []Payment       `bson:"payments" json:"payments"`
 	CreatedAt               string          `bson:"created_at" json:"created_at"`
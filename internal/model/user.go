@@ -16,6 +16,10 @@ type Address struct {
 	Country  string `bson:"country,omitempty" json:"country,omitempty"`
 }
 
+// UserRoleSupport is granted to support staff, who may read every user's orders. It is
+// assigned in the database; registration never sets a role.
+const UserRoleSupport = "support"
+
 type User struct {
 	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	Email          string             `bson:"email,omitempty" json:"emailId,omitempty"`
@@ -23,6 +27,7 @@ type User struct {
 	LastName       string             `bson:"lastName,omitempty" json:"lastName,omitempty"`
 	Password       string             `bson:"password,omitempty" json:"password,omitempty"`
 	MobileNumber   string             `bson:"mobileNumber,omitempty" json:"mobileNumber,omitempty"`
+	Role           string             `bson:"role,omitempty" json:"role,omitempty"`
 	BillingAddress Address            `bson:"billingAddress,omitempty" json:"billingAddress,omitempty"`
 	CreatedAt      time.Time          `bson:"createdAt,omitempty" json:"createdAt,omitempty"`
 	UpdatedAt      time.Time          `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Webhook event types sent to merchant endpoints. Order events are named
+// "order.<status>" after the status the order moved to.
+const (
+	WebhookEventOrderPrefix        = "order."
+	WebhookEventOrderProcessed     = "order.processed"
+	WebhookEventOrderFailed        = "order.failed"
+	WebhookEventOrderCancelled     = "order.cancelled"
+	WebhookEventOrderExpired       = "order.expired"
+	WebhookEventRefundSucceeded    = "refund.succeeded"
+	WebhookEventRefundFailed       = "refund.failed"
+	WebhookEventBulkOrderCompleted = "bulk_order.completed"
+)
+
+// WebhookEndpoint is a merchant URL subscribed to gateway events. An empty Events
+// list subscribes to every event. An endpoint only receives events for the orders,
+// refunds and bulk orders of the user who created it.
+type WebhookEndpoint struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	CreatedBy   string             `bson:"created_by" json:"created_by"`
+	URL         string             `bson:"url" json:"url"`
+	Secret      string             `bson:"secret" json:"secret,omitempty"`
+	Events      []string           `bson:"events" json:"events"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	Active      bool               `bson:"active" json:"active"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Subscribes reports whether the endpoint wants events of the given type.
+func (e WebhookEndpoint) Subscribes(eventType string) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, event := range e.Events {
+		if event == "*" || event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEvent is the JSON envelope POSTed to merchant endpoints.
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event addressed to one endpoint, with its retry state.
+type WebhookDelivery struct {
+	ID             primitive.ObjectID    `bson:"_id,omitempty" json:"id,omitempty"`
+	EndpointID     primitive.ObjectID    `bson:"endpoint_id" json:"endpoint_id"`
+	CreatedBy      string                `bson:"created_by" json:"-"`
+	EventID        string                `bson:"event_id" json:"event_id"`
+	EventType      string                `bson:"event_type" json:"event_type"`
+	Payload        string                `bson:"payload" json:"payload"`
+	Status         WebhookDeliveryStatus `bson:"status" json:"status"`
+	Attempts       int                   `bson:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time             `bson:"next_attempt_at" json:"next_attempt_at"`
+	LastAttemptAt  *time.Time            `bson:"last_attempt_at,omitempty" json:"last_attempt_at,omitempty"`
+	LastStatusCode int                   `bson:"last_status_code,omitempty" json:"last_status_code,omitempty"`
+	LastError      string                `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	CreatedAt      time.Time             `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time             `bson:"updated_at" json:"updated_at"`
+}
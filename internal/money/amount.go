@@ -0,0 +1,75 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// Amount is a count of minor currency units. It is stored in Mongo as an int64
+// and still decodes documents written when amounts were floating point.
+type Amount int64
+
+// MarshalBSONValue stores the amount as a BSON int64.
+func (a Amount) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bsontype.Int64, bsoncore.AppendInt64(nil, int64(a)), nil
+}
+
+// UnmarshalBSONValue accepts int32, int64 and legacy double values.
+func (a *Amount) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	switch t {
+	case bsontype.Int64:
+		v, _, ok := bsoncore.ReadInt64(data)
+		if !ok {
+			return fmt.Errorf("money: malformed int64 amount")
+		}
+		*a = Amount(v)
+	case bsontype.Int32:
+		v, _, ok := bsoncore.ReadInt32(data)
+		if !ok {
+			return fmt.Errorf("money: malformed int32 amount")
+		}
+		*a = Amount(v)
+	case bsontype.Double:
+		v, _, ok := bsoncore.ReadDouble(data)
+		if !ok {
+			return fmt.Errorf("money: malformed double amount")
+		}
+		*a = Amount(math.Round(v))
+	case bsontype.Null, bsontype.Undefined:
+		*a = 0
+	default:
+		return fmt.Errorf("money: cannot decode %s into an amount", t)
+	}
+	return nil
+}
+
+// UnmarshalJSON accepts only whole numbers of minor units, so that a major-unit
+// value such as 10.5 is rejected instead of being silently rounded.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("money: amount must be a number of minor units: %w", err)
+	}
+	if v, err := strconv.ParseInt(n.String(), 10, 64); err == nil {
+		*a = Amount(v)
+		return nil
+	}
+
+	// Providers sometimes send integral values as 1000.0.
+	f, err := n.Float64()
+	if err != nil || f != math.Trunc(f) || math.Abs(f) > math.MaxInt64 {
+		return fmt.Errorf("money: amount %s is not a whole number of minor units", n)
+	}
+	*a = Amount(f)
+	return nil
+}
@@ -0,0 +1,133 @@
+package money_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aakritigkmit/payment-gateway/internal/money"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type amountDoc struct {
+	Amount money.Amount `bson:"amount" json:"amount"`
+	Money  money.Money  `bson:"money" json:"money"`
+}
+
+func TestAmountBSONRoundTrip(t *testing.T) {
+	want := amountDoc{Amount: 123456789012, Money: money.New(1234, "BHD")}
+	data, err := bson.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	raw := bson.Raw(data)
+	if typ := raw.Lookup("amount").Type; typ != bson.TypeInt64 {
+		t.Errorf("amount stored as %s, want int64", typ)
+	}
+	if typ := raw.Lookup("money", "value").Type; typ != bson.TypeInt64 {
+		t.Errorf("money.value stored as %s, want int64", typ)
+	}
+
+	var got amountDoc
+	if err := bson.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got != want {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestAmountDecodesLegacyBSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  money.Amount
+	}{
+		{"int32", int32(1250), 1250},
+		{"int64", int64(1250), 1250},
+		{"double", 1250.0, 1250},
+		{"double rounded up", 1249.6, 1250},
+		{"double rounded down", 1250.4, 1250},
+		{"null", nil, 0},
+	}
+	for _, tt := range tests {
+		data, err := bson.Marshal(bson.M{"amount": tt.value})
+		if err != nil {
+			t.Fatalf("%s: Marshal: %v", tt.name, err)
+		}
+		var got amountDoc
+		if err := bson.Unmarshal(data, &got); err != nil {
+			t.Errorf("%s: Unmarshal: %v", tt.name, err)
+			continue
+		}
+		if got.Amount != tt.want {
+			t.Errorf("%s: amount = %d, want %d", tt.name, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestAmountRejectsNonNumericBSON(t *testing.T) {
+	data, err := bson.Marshal(bson.M{"amount": "12.50"})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var got amountDoc
+	if err := bson.Unmarshal(data, &got); err == nil {
+		t.Errorf("Unmarshal of a string amount = %+v, want an error", got)
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	tests := []struct {
+		body    string
+		want    money.Amount
+		wantErr bool
+	}{
+		{body: `{"amount": 1250}`, want: 1250},
+		{body: `{"amount": -50}`, want: -50},
+		{body: `{"amount": 1000.0}`, want: 1000},
+		{body: `{"amount": 1e3}`, want: 1000},
+		{body: `{"amount": null}`, want: 0},
+		{body: `{"amount": 10.5}`, wantErr: true},
+		{body: `{"amount": "1250"}`, want: 1250},
+		{body: `{"amount": "12.50"}`, wantErr: true},
+		{body: `{"amount": "abc"}`, wantErr: true},
+		{body: `{"amount": 1e30}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var got amountDoc
+		err := json.Unmarshal([]byte(tt.body), &got)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Unmarshal(%s) = %d, want an error", tt.body, got.Amount)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.body, err)
+			continue
+		}
+		if got.Amount != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, want %d", tt.body, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestMoneyJSONRoundTrip(t *testing.T) {
+	want := money.New(1200, "JPY")
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(data) != `{"value":1200,"currency":"JPY"}` {
+		t.Errorf("Marshal = %s, want the Pine Labs amount shape", data)
+	}
+
+	var got money.Money
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got != want {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,144 @@
+// Package money represents monetary amounts as integer minor units (paise, cents)
+// so that order, capture and refund arithmetic is exact.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidCurrency is returned for currency codes that are not three uppercase letters.
+	ErrInvalidCurrency = errors.New("invalid ISO 4217 currency code")
+	// ErrCurrencyMismatch is returned when combining amounts in different currencies.
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// exponents lists ISO 4217 currencies whose minor unit is not 1/100 of the major unit.
+var exponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// Exponent returns the number of minor-unit digits for an ISO 4217 currency.
+func Exponent(currency string) int {
+	if exp, ok := exponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+// ValidateCurrency checks that currency looks like an ISO 4217 code.
+func ValidateCurrency(currency string) error {
+	if !currencyCodePattern.MatchString(currency) {
+		return fmt.Errorf("%w: %q", ErrInvalidCurrency, currency)
+	}
+	return nil
+}
+
+// Money is an amount in minor units of an ISO 4217 currency. It serialises as
+// {"value": <minor units>, "currency": "INR"}, the shape Pine Labs uses.
+type Money struct {
+	Value    Amount `json:"value" bson:"value"`
+	Currency string `json:"currency" bson:"currency"`
+}
+
+// New returns value minor units of currency.
+func New(value int64, currency string) Money {
+	return Money{Value: Amount(value), Currency: strings.ToUpper(currency)}
+}
+
+// Parse converts a decimal major-unit string such as "12.50" into Money, rejecting
+// more fractional digits than the currency allows.
+func Parse(major, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	if err := ValidateCurrency(currency); err != nil {
+		return Money{}, err
+	}
+	exp := Exponent(currency)
+
+	s := strings.TrimSpace(major)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" || len(frac) > exp {
+		return Money{}, fmt.Errorf("invalid amount %q for %s", major, currency)
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	value, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q for %s: %w", major, currency, err)
+	}
+	if negative {
+		value = -value
+	}
+	return New(value, currency), nil
+}
+
+// Validate checks the currency code and that the amount is not negative.
+func (m Money) Validate() error {
+	if err := ValidateCurrency(m.Currency); err != nil {
+		return err
+	}
+	if m.Value < 0 {
+		return fmt.Errorf("amount %d must not be negative", m.Value)
+	}
+	return nil
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.Value == 0
+}
+
+// Add returns m + other. Both amounts must share a currency.
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	return Money{Value: m.Value + other.Value, Currency: m.Currency}, nil
+}
+
+// Sub returns m - other. Both amounts must share a currency.
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	return Money{Value: m.Value - other.Value, Currency: m.Currency}, nil
+}
+
+func (m Money) sameCurrency(other Money) error {
+	if !strings.EqualFold(m.Currency, other.Currency) {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return nil
+}
+
+// String formats the amount in major units, e.g. "12.50 INR".
+func (m Money) String() string {
+	exp := Exponent(m.Currency)
+	value := int64(m.Value)
+	sign := ""
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	if exp == 0 {
+		return fmt.Sprintf("%s%d %s", sign, value, m.Currency)
+	}
+
+	unit := int64(1)
+	for i := 0; i < exp; i++ {
+		unit *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d %s", sign, value/unit, exp, value%unit, m.Currency)
+}
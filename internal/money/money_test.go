@@ -0,0 +1,123 @@
+package money_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aakritigkmit/payment-gateway/internal/money"
+)
+
+func TestExponent(t *testing.T) {
+	tests := []struct {
+		currency string
+		want     int
+	}{
+		{"JPY", 0},
+		{"jpy", 0},
+		{"INR", 2},
+		{"USD", 2},
+		{"BHD", 3},
+		{"CLF", 4},
+	}
+	for _, tt := range tests {
+		if got := money.Exponent(tt.currency); got != tt.want {
+			t.Errorf("Exponent(%q) = %d, want %d", tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		major    string
+		currency string
+		want     money.Money
+		wantErr  bool
+	}{
+		{major: "1200", currency: "JPY", want: money.New(1200, "JPY")},
+		{major: "1200.5", currency: "JPY", wantErr: true},
+		{major: "12.50", currency: "INR", want: money.New(1250, "INR")},
+		{major: "12.5", currency: "inr", want: money.New(1250, "INR")},
+		{major: "12", currency: "INR", want: money.New(1200, "INR")},
+		{major: "0.01", currency: "INR", want: money.New(1, "INR")},
+		{major: "-3.25", currency: "INR", want: money.New(-325, "INR")},
+		{major: "12.505", currency: "INR", wantErr: true},
+		{major: "1.234", currency: "BHD", want: money.New(1234, "BHD")},
+		{major: "1.2", currency: "BHD", want: money.New(1200, "BHD")},
+		{major: "1.2345", currency: "BHD", wantErr: true},
+		{major: ".50", currency: "INR", wantErr: true},
+		{major: "1,000", currency: "INR", wantErr: true},
+		{major: "10", currency: "RUPEES", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := money.Parse(tt.major, tt.currency)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q, %q) = %v, want an error", tt.major, tt.currency, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q, %q): %v", tt.major, tt.currency, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q, %q) = %+v, want %+v", tt.major, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestParseRejectsInvalidCurrency(t *testing.T) {
+	if _, err := money.Parse("10", "RS"); !errors.Is(err, money.ErrInvalidCurrency) {
+		t.Errorf("err = %v, want ErrInvalidCurrency", err)
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		m    money.Money
+		want string
+	}{
+		{money.New(1200, "JPY"), "1200 JPY"},
+		{money.New(1250, "INR"), "12.50 INR"},
+		{money.New(5, "INR"), "0.05 INR"},
+		{money.New(-325, "INR"), "-3.25 INR"},
+		{money.New(1234, "BHD"), "1.234 BHD"},
+		{money.New(7, "BHD"), "0.007 BHD"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.m, got, tt.want)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	sum, err := money.New(1250, "INR").Add(money.New(75, "inr"))
+	if err != nil || sum != money.New(1325, "INR") {
+		t.Errorf("Add = %+v, %v; want 13.25 INR", sum, err)
+	}
+	diff, err := money.New(1250, "INR").Sub(money.New(1300, "INR"))
+	if err != nil || diff != money.New(-50, "INR") {
+		t.Errorf("Sub = %+v, %v; want -0.50 INR", diff, err)
+	}
+	if _, err := money.New(1250, "INR").Add(money.New(1250, "JPY")); !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Errorf("Add across currencies: err = %v, want ErrCurrencyMismatch", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		m       money.Money
+		wantErr bool
+	}{
+		{money.New(0, "INR"), false},
+		{money.New(1250, "BHD"), false},
+		{money.New(-1, "INR"), true},
+		{money.Money{Value: 100, Currency: "inr"}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.m.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v.Validate() = %v, want error %v", tt.m, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aakritigkmit/payment-gateway/internal/dto"
+	"github.com/aakritigkmit/payment-gateway/internal/helpers"
+	"github.com/aakritigkmit/payment-gateway/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const PineLabs = "pinelabs"
+
+// PineLabsProvider adapts the Pine Labs client in utils to the PaymentProvider interface.
+type PineLabsProvider struct{}
+
+func NewPineLabsProvider() *PineLabsProvider {
+	return &PineLabsProvider{}
+}
+
+func (p *PineLabsProvider) Name() string {
+	return PineLabs
+}
+
+func (p *PineLabsProvider) FetchAccessToken(ctx context.Context) (string, error) {
+	tokenResp, err := utils.FetchAccessToken(ctx)
+	if err != nil {
+		return "", wrapPineLabsErr(err)
+	}
+	return tokenResp.AccessToken, nil
+}
+
+func (p *PineLabsProvider) CreateOrder(ctx context.Context, req dto.PlaceOrderRequest) (CheckoutSession, error) {
+	token, err := p.FetchAccessToken(ctx)
+	if err != nil {
+		return CheckoutSession{}, err
+	}
+
+	jsonPayload, err := helpers.BuildOrderPayload(req)
+	if err != nil {
+		return CheckoutSession{}, err
+	}
+
+	resp, err := utils.CreateOrderRequest(ctx, token, jsonPayload)
+	if err != nil {
+		return CheckoutSession{}, wrapPineLabsErr(err)
+	}
+	return CheckoutSession{
+		OrderID:     resp.OrderID,
+		Token:       resp.Token,
+		RedirectURL: resp.RedirectURL,
+	}, nil
+}
+
+func (p *PineLabsProvider) GetOrder(ctx context.Context, orderID string) (OrderResult, error) {
+	token, err := p.FetchAccessToken(ctx)
+	if err != nil {
+		return OrderResult{}, err
+	}
+
+	resp, err := utils.GetOrderDetails(ctx, token, orderID)
+	if err != nil {
+		return OrderResult{}, err
+	}
+	return pineOrderResult(resp), nil
+}
+
+func (p *PineLabsProvider) CreateRefund(ctx context.Context, orderID string, params RefundParams) (RefundResult, error) {
+	token, err := p.FetchAccessToken(ctx)
+	if err != nil {
+		return RefundResult{}, err
+	}
+
+	refundPayload := map[string]interface{}{
+		"merchant_order_reference": params.MerchantRefundReference,
+		"order_amount":             params.Amount,
+		"merchant_metadata":        params.Metadata,
+	}
+
+	jsonPayload, err := json.Marshal(refundPayload)
+	if err != nil {
+		return RefundResult{}, fmt.Errorf("failed to marshal refund payload: %w", err)
+	}
+
+	resp, err := utils.CreateRefundRequest(ctx, token, orderID, jsonPayload)
+	if err != nil {
+		return RefundResult{}, wrapPineLabsErr(err)
+	}
+	return RefundResult{
+		RefundID: resp.Data.OrderID,
+		Status:   resp.Data.Status,
+		Raw:      resp,
+	}, nil
+}
+
+func (p *PineLabsProvider) CaptureOrder(ctx context.Context, orderID string, params CaptureParams) (OrderResult, error) {
+	token, err := p.FetchAccessToken(ctx)
+	if err != nil {
+		return OrderResult{}, err
+	}
+
+	capturePayload := map[string]interface{}{
+		"merchant_capture_reference": params.MerchantCaptureReference,
+		"capture_amount":             params.Amount,
+	}
+
+	jsonPayload, err := json.Marshal(capturePayload)
+	if err != nil {
+		return OrderResult{}, fmt.Errorf("failed to marshal capture payload: %w", err)
+	}
+
+	resp, err := utils.CaptureOrderRequest(ctx, token, orderID, jsonPayload)
+	if err != nil {
+		return OrderResult{}, wrapPineLabsErr(err)
+	}
+	return pineOrderResult(resp), nil
+}
+
+func (p *PineLabsProvider) CancelOrder(ctx context.Context, orderID string) (OrderResult, error) {
+	token, err := p.FetchAccessToken(ctx)
+	if err != nil {
+		return OrderResult{}, err
+	}
+
+	resp, err := utils.CancelOrderRequest(ctx, token, orderID)
+	if err != nil {
+		return OrderResult{}, wrapPineLabsErr(err)
+	}
+	return pineOrderResult(resp), nil
+}
+
+// pineOrderResult converts a Pine Labs order payload into an OrderResult.
+func pineOrderResult(resp *dto.PineOrderResponse) OrderResult {
+	status, _ := helpers.MapPineStatusToOrderStatus(resp.Data.Status)
+	return OrderResult{
+		OrderID:     resp.Data.OrderID,
+		Status:      resp.Data.Status,
+		OrderStatus: status,
+		Transaction: helpers.MapPineOrderToTransactionModel(resp),
+		Refunds:     helpers.MapRefundsToTransactionModel(resp, primitive.NilObjectID).Refunds,
+		Raw:         resp,
+	}
+}
+
+// wrapPineLabsErr tags Pine Labs outages with ErrProviderUnavailable so the
+// registry knows a fallback acquirer may be tried, and 4xx answers with
+// ErrProviderRejected.
+func wrapPineLabsErr(err error) error {
+	switch {
+	case errors.Is(err, utils.ErrPineLabsUnavailable):
+		return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	case errors.Is(err, utils.ErrPineLabsRejected):
+		return fmt.Errorf("%w: %v", ErrProviderRejected, err)
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package providers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aakritigkmit/payment-gateway/internal/dto"
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"github.com/aakritigkmit/payment-gateway/internal/money"
+)
+
+// ErrProviderUnavailable is returned (wrapped) when an acquirer cannot be reached
+// or answers with a server-side error, i.e. when a fallback acquirer may be tried.
+var ErrProviderUnavailable = errors.New("payment provider unavailable")
+
+// ErrProviderRejected is returned (wrapped) when an acquirer definitely refused a
+// request, so nothing was applied on its side.
+var ErrProviderRejected = errors.New("payment provider rejected the request")
+
+// RefundParams carries the provider-agnostic fields needed to raise a refund.
+type RefundParams struct {
+	MerchantRefundReference string
+	Amount                  money.Money
+	Metadata                map[string]string
+}
+
+// CaptureParams describes a full or partial capture of a pre-authorized order.
+type CaptureParams struct {
+	MerchantCaptureReference string
+	Amount                   money.Money
+}
+
+// CheckoutSession is an acquirer's answer to a new order: its order ID and where
+// to send the customer to pay.
+type CheckoutSession struct {
+	OrderID     string `json:"order_id"`
+	Token       string `json:"token"`
+	RedirectURL string `json:"redirect_url"`
+}
+
+// OrderResult is an acquirer's view of an order after a fetch, capture or cancel.
+type OrderResult struct {
+	OrderID string
+	// Status is the acquirer's own order status, e.g. "PROCESSED".
+	Status string
+	// OrderStatus is Status translated to the gateway lifecycle; empty when the
+	// acquirer reported a status the gateway does not know.
+	OrderStatus model.OrderStatus
+	// Transaction holds the order and its payments as stored on model.Transaction.
+	Transaction model.Transaction
+	// Refunds lists the refunds the acquirer has recorded against the order.
+	Refunds []model.Refund
+	// Raw is the acquirer's payload, returned unchanged to API callers.
+	Raw interface{}
+}
+
+// RefundResult is an acquirer's answer to a refund request.
+type RefundResult struct {
+	// RefundID is the acquirer's identifier for the refund.
+	RefundID string
+	// Status is the acquirer's own refund status.
+	Status string
+	// Raw is the acquirer's payload, kept for audit.
+	Raw interface{}
+}
+
+// PaymentProvider is implemented by every acquirer the gateway can route orders to.
+type PaymentProvider interface {
+	// Name is the identifier stored on model.Transaction.Provider.
+	Name() string
+	FetchAccessToken(ctx context.Context) (string, error)
+	CreateOrder(ctx context.Context, req dto.PlaceOrderRequest) (CheckoutSession, error)
+	GetOrder(ctx context.Context, orderID string) (OrderResult, error)
+	CreateRefund(ctx context.Context, orderID string, params RefundParams) (RefundResult, error)
+	CaptureOrder(ctx context.Context, orderID string, params CaptureParams) (OrderResult, error)
+	// CancelOrder cancels an unpaid order or voids a pre-authorization.
+	CancelOrder(ctx context.Context, orderID string) (OrderResult, error)
+}
@@ -0,0 +1,86 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/aakritigkmit/payment-gateway/internal/config"
+	"github.com/aakritigkmit/payment-gateway/internal/dto"
+)
+
+// Registry resolves which PaymentProvider handles an order. The order's own
+// provider wins, then the merchant's configured provider, then the default.
+type Registry struct {
+	providers         map[string]PaymentProvider
+	defaultName       string
+	fallbackName      string
+	merchantProviders map[string]string
+}
+
+func NewRegistry(cfg *config.Config) *Registry {
+	r := &Registry{
+		providers:         make(map[string]PaymentProvider),
+		defaultName:       cfg.PaymentProvider,
+		fallbackName:      cfg.PaymentFallbackProvider,
+		merchantProviders: cfg.MerchantPaymentProviders,
+	}
+	r.Register(NewPineLabsProvider())
+	return r
+}
+
+// Register adds (or replaces) a provider under its Name.
+func (r *Registry) Register(p PaymentProvider) {
+	r.providers[p.Name()] = p
+}
+
+// Get returns the named provider. An empty name resolves to the default provider,
+// which keeps transactions stored before providers were recorded working.
+func (r *Registry) Get(name string) (PaymentProvider, error) {
+	if name == "" {
+		name = r.defaultName
+	}
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown payment provider %q", name)
+	}
+	return p, nil
+}
+
+// Resolve picks the provider for a new order.
+func (r *Registry) Resolve(requested, merchantID string) (PaymentProvider, error) {
+	if requested != "" {
+		return r.Get(requested)
+	}
+	if name, ok := r.merchantProviders[merchantID]; ok && merchantID != "" {
+		return r.Get(name)
+	}
+	return r.Get(r.defaultName)
+}
+
+// CreateOrder places the order with the primary provider and, if it is unavailable,
+// retries once on the configured fallback provider. It returns the provider that
+// accepted the order so the caller can persist it.
+func (r *Registry) CreateOrder(ctx context.Context, primary PaymentProvider, req dto.PlaceOrderRequest) (PaymentProvider, CheckoutSession, error) {
+	resp, err := primary.CreateOrder(ctx, req)
+	if err == nil {
+		return primary, resp, nil
+	}
+
+	if !errors.Is(err, ErrProviderUnavailable) || r.fallbackName == "" || r.fallbackName == primary.Name() {
+		return nil, CheckoutSession{}, err
+	}
+
+	fallback, ferr := r.Get(r.fallbackName)
+	if ferr != nil {
+		return nil, CheckoutSession{}, err
+	}
+
+	log.Printf("[Provider] %s unavailable (%v), falling back to %s", primary.Name(), err, fallback.Name())
+	resp, err = fallback.CreateOrder(ctx, req)
+	if err != nil {
+		return nil, CheckoutSession{}, err
+	}
+	return fallback, resp, nil
+}
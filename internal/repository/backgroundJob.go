@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrBackgroundJobNotFound is returned when no background job matches the given ID.
+var ErrBackgroundJobNotFound = errors.New("job not found")
+
+type BackgroundJobRepo struct {
+	collection *mongo.Collection
+}
+
+func NewBackgroundJobRepo(db *mongo.Database) *BackgroundJobRepo {
+	return &BackgroundJobRepo{collection: db.Collection("background_jobs")}
+}
+
+func (r *BackgroundJobRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "updated_at", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create background job indexes: %w", err)
+	}
+	return nil
+}
+
+func (r *BackgroundJobRepo) Create(ctx context.Context, job model.BackgroundJob) (model.BackgroundJob, error) {
+	result, err := r.collection.InsertOne(ctx, job)
+	if err != nil {
+		return model.BackgroundJob{}, fmt.Errorf("failed to create %s job: %w", job.Type, err)
+	}
+	job.ID = result.InsertedID.(primitive.ObjectID)
+	return job, nil
+}
+
+func (r *BackgroundJobRepo) Get(ctx context.Context, id primitive.ObjectID) (model.BackgroundJob, error) {
+	var job model.BackgroundJob
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return model.BackgroundJob{}, ErrBackgroundJobNotFound
+		}
+		return model.BackgroundJob{}, fmt.Errorf("failed to fetch job: %w", err)
+	}
+	return job, nil
+}
+
+// SaveProgress stores a running job's progress and reports whether cancellation has
+// been requested.
+func (r *BackgroundJobRepo) SaveProgress(ctx context.Context, id primitive.ObjectID, progress model.JobProgress,
+	saveErrors []model.ProductSaveError, fetchErrors []model.FetchPageError) (bool, error) {
+	update := bson.M{"$set": bson.M{
+		"progress":     progress,
+		"save_errors":  saveErrors,
+		"fetch_errors": fetchErrors,
+		"updated_at":   time.Now(),
+	}}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"cancel_requested": 1})
+
+	var job model.BackgroundJob
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&job)
+	if err != nil {
+		return false, fmt.Errorf("failed to save progress of job %s: %w", id.Hex(), err)
+	}
+	return job.CancelRequested, nil
+}
+
+// Finish moves a running job to its final status.
+func (r *BackgroundJobRepo) Finish(ctx context.Context, id primitive.ObjectID, status model.BackgroundJobStatus, errMsg string) error {
+	now := time.Now()
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": model.BackgroundJobRunning}, bson.M{"$set": bson.M{
+		"status":      status,
+		"error":       errMsg,
+		"finished_at": now,
+		"updated_at":  now,
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to finish job %s: %w", id.Hex(), err)
+	}
+	return nil
+}
+
+// FailStale fails running jobs whose progress has not been saved since before, i.e.
+// whose replica stopped heartbeating. ids, if given, limits the jobs considered.
+func (r *BackgroundJobRepo) FailStale(ctx context.Context, before time.Time, ids ...primitive.ObjectID) (int64, error) {
+	filter := bson.M{"status": model.BackgroundJobRunning, "updated_at": bson.M{"$lt": before}}
+	if len(ids) > 0 {
+		filter["_id"] = bson.M{"$in": ids}
+	}
+	now := time.Now()
+	result, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{
+		"status":      model.BackgroundJobFailed,
+		"error":       fmt.Sprintf("no heartbeat since %s", before.UTC().Format(time.RFC3339)),
+		"finished_at": now,
+		"updated_at":  now,
+	}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail stale jobs: %w", err)
+	}
+	return result.ModifiedCount, nil
+}
+
+// RequestCancel flags a running job for cancellation and returns it. The job is
+// returned unchanged if it has already finished.
+func (r *BackgroundJobRepo) RequestCancel(ctx context.Context, id primitive.ObjectID) (model.BackgroundJob, error) {
+	filter := bson.M{"_id": id, "status": model.BackgroundJobRunning}
+	update := bson.M{"$set": bson.M{"cancel_requested": true, "updated_at": time.Now()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var job model.BackgroundJob
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return r.Get(ctx, id)
+	}
+	if err != nil {
+		return model.BackgroundJob{}, fmt.Errorf("failed to cancel job %s: %w", id.Hex(), err)
+	}
+	return job, nil
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type CallbackRepo struct {
+	rejectionCollection *mongo.Collection
+}
+
+func NewCallbackRepo(db *mongo.Database) *CallbackRepo {
+	return &CallbackRepo{
+		rejectionCollection: db.Collection("callback_rejections"),
+	}
+}
+
+// SaveRejection stores a callback that failed signature or replay checks.
+func (r *CallbackRepo) SaveRejection(ctx context.Context, rejection model.CallbackRejection) error {
+	_, err := r.rejectionCollection.InsertOne(ctx, rejection)
+	if err != nil {
+		return fmt.Errorf("failed to save callback rejection: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IdempotencyRepo struct {
+	collection *mongo.Collection
+	ttl        time.Duration
+}
+
+func NewIdempotencyRepo(db *mongo.Database, ttl time.Duration) *IdempotencyRepo {
+	return &IdempotencyRepo{
+		collection: db.Collection("idempotency_keys"),
+		ttl:        ttl,
+	}
+}
+
+// EnsureIndexes creates the unique key index and the TTL index that expires old records.
+func (r *IdempotencyRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(r.ttl.Seconds())),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create idempotency indexes: %w", err)
+	}
+	return nil
+}
+
+// FindByKey returns the stored record for key, or nil if there is none.
+func (r *IdempotencyRepo) FindByKey(ctx context.Context, key string) (*model.IdempotencyRecord, error) {
+	var record model.IdempotencyRecord
+	err := r.collection.FindOne(ctx, bson.M{"key": key}).Decode(&record)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find idempotency record: %w", err)
+	}
+	return &record, nil
+}
+
+func (r *IdempotencyRepo) SaveRecord(ctx context.Context, record model.IdempotencyRecord) error {
+	_, err := r.collection.InsertOne(ctx, record)
+	if err != nil {
+		return fmt.Errorf("failed to save idempotency record: %w", err)
+	}
+	return nil
+}
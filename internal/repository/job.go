@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrJobRunNotFound is returned when no job run matches the given ID.
+var ErrJobRunNotFound = errors.New("job run not found")
+
+type JobRepo struct {
+	jobs *mongo.Collection
+	runs *mongo.Collection
+}
+
+func NewJobRepo(db *mongo.Database) *JobRepo {
+	return &JobRepo{
+		jobs: db.Collection("scheduled_jobs"),
+		runs: db.Collection("job_runs"),
+	}
+}
+
+// EnsureIndexes creates the indexes used by the run history listing.
+func (r *JobRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.runs.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "job", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "_id", Value: -1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create job run indexes: %w", err)
+	}
+	return nil
+}
+
+// RegisterJob records a job's schedule and next run time.
+func (r *JobRepo) RegisterJob(ctx context.Context, name, schedule string, nextRunAt time.Time) error {
+	update := bson.M{"$set": bson.M{
+		"schedule":    schedule,
+		"next_run_at": nextRunAt,
+		"updated_at":  time.Now(),
+	}}
+	opts := options.Update().SetUpsert(true)
+	if _, err := r.jobs.UpdateOne(ctx, bson.M{"_id": name}, update, opts); err != nil {
+		return fmt.Errorf("failed to register job %s: %w", name, err)
+	}
+	return nil
+}
+
+func (r *JobRepo) ListJobs(ctx context.Context) ([]model.ScheduledJob, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := r.jobs.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch jobs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	jobs := []model.ScheduledJob{}
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to decode jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// StartRun inserts a run in the running state.
+func (r *JobRepo) StartRun(ctx context.Context, run model.JobRun) (model.JobRun, error) {
+	result, err := r.runs.InsertOne(ctx, run)
+	if err != nil {
+		return model.JobRun{}, fmt.Errorf("failed to record %s run: %w", run.Job, err)
+	}
+	run.ID = result.InsertedID.(primitive.ObjectID)
+	return run, nil
+}
+
+// FinishRun stores the outcome of a run and updates the job's last run fields.
+func (r *JobRepo) FinishRun(ctx context.Context, run model.JobRun) error {
+	_, err := r.runs.UpdateOne(ctx, bson.M{"_id": run.ID}, bson.M{"$set": bson.M{
+		"status":      run.Status,
+		"finished_at": run.FinishedAt,
+		"duration_ms": run.DurationMs,
+		"summary":     run.Summary,
+		"error":       run.Error,
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to finish %s run: %w", run.Job, err)
+	}
+
+	_, err = r.jobs.UpdateOne(ctx, bson.M{"_id": run.Job}, bson.M{"$set": bson.M{
+		"last_run_at": run.StartedAt,
+		"last_run_id": run.ID,
+		"last_status": run.Status,
+		"updated_at":  time.Now(),
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to update job %s: %w", run.Job, err)
+	}
+	return nil
+}
+
+func (r *JobRepo) GetRun(ctx context.Context, id primitive.ObjectID) (model.JobRun, error) {
+	var run model.JobRun
+	err := r.runs.FindOne(ctx, bson.M{"_id": id}).Decode(&run)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return model.JobRun{}, ErrJobRunNotFound
+		}
+		return model.JobRun{}, fmt.Errorf("failed to fetch job run: %w", err)
+	}
+	return run, nil
+}
+
+// ListRuns returns runs newest first, optionally filtered by job and status.
+func (r *JobRepo) ListRuns(ctx context.Context, job, status, cursor string, limit int64) ([]model.JobRun, error) {
+	filter := bson.M{}
+	if job != "" {
+		filter["job"] = job
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+	if cursor != "" {
+		cursorID, err := primitive.ObjectIDFromHex(cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		filter["_id"] = bson.M{"$lt": cursorID}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(limit)
+	cur, err := r.runs.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch job runs: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	runs := []model.JobRun{}
+	if err := cur.All(ctx, &runs); err != nil {
+		return nil, fmt.Errorf("failed to decode job runs: %w", err)
+	}
+	return runs, nil
+}
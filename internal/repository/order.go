@@ -2,15 +2,32 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/aakritigkmit/payment-gateway/internal/dto"
 	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"github.com/aakritigkmit/payment-gateway/internal/money"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var (
+	// ErrOrderNotFound is returned when no order matches the lookup.
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrInvalidStatusTransition is returned when the order state machine forbids a transition.
+	ErrInvalidStatusTransition = errors.New("invalid order status transition")
+	// ErrRefundBalanceExceeded is returned when a refund would take the refunded total past the captured amount.
+	ErrRefundBalanceExceeded = errors.New("refund exceeds refundable balance")
+	// ErrStatusTransitionConflict is returned when the order kept changing underneath the update.
+	ErrStatusTransitionConflict = errors.New("order status changed concurrently")
+)
+
+const maxTransitionAttempts = 3
+
 type OrderRepo struct {
 	collection               *mongo.Collection
 	collectionRefund         *mongo.Collection
@@ -35,10 +52,8 @@ func (r *OrderRepo) UpdateOrder(referenceID string, payload *dto.UpdateOrderPayl
 		return fmt.Errorf("empty transaction reference ID")
 	}
 
+	// Status changes go through TransitionStatus so the state machine is enforced.
 	update := bson.M{}
-	if payload.Status != "" {
-		update["status"] = payload.Status
-	}
 	if payload.Amount != 0 {
 		update["amount"] = payload.Amount
 	}
@@ -85,25 +100,326 @@ func (r *OrderRepo) GetOrderByTransactionReferenceId(ctx context.Context, refere
 	err := r.collection.FindOne(ctx, query).Decode(&order)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return model.Order{}, fmt.Errorf("order not found")
+			return model.Order{}, ErrOrderNotFound
+		}
+		return model.Order{}, err
+	}
+	return order, nil
+}
+
+// EnsureIndexes creates the indexes used by order lookups and search.
+func (r *OrderRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "transactionReferenceId", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "createdBy", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "currency", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "lastReconciledAt", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "expiryRetryAt", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create order indexes: %w", err)
+	}
+	return nil
+}
+
+// GetOrderByObjectID looks an order up by its Mongo _id.
+func (r *OrderRepo) GetOrderByObjectID(ctx context.Context, id primitive.ObjectID) (model.Order, error) {
+	var order model.Order
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&order)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return model.Order{}, ErrOrderNotFound
 		}
 		return model.Order{}, err
 	}
 	return order, nil
 }
 
-func (r *OrderRepo) UpdateOrderRefund(ctx context.Context, order model.Order) error {
-	filter := bson.M{"_id": order.ID}
+// SearchOrders returns one page of orders, newest first. params.CreatedBy, when set,
+// restricts it to that user's orders. orderIDs, when non-nil, restricts the result to
+// those transaction reference IDs.
+func (r *OrderRepo) SearchOrders(ctx context.Context, params dto.OrderSearchParams, orderIDs []string) ([]model.Order, error) {
+	filter := bson.M{}
+	if params.CreatedBy != "" {
+		filter["createdBy"] = params.CreatedBy
+	}
+	if params.Status != "" {
+		filter["status"] = bson.M{"$in": model.StoredStatusValues(model.OrderStatus(params.Status))}
+	}
+	if params.CustomerID != "" {
+		filter["userId"] = params.CustomerID
+	}
+	if params.Currency != "" {
+		filter["currency"] = params.Currency
+	}
+	if orderIDs != nil {
+		filter["transactionReferenceId"] = bson.M{"$in": orderIDs}
+	}
+
+	createdAt := bson.M{}
+	if !params.From.IsZero() {
+		createdAt["$gte"] = params.From
+	}
+	if !params.To.IsZero() {
+		createdAt["$lt"] = params.To
+	}
+	if len(createdAt) > 0 {
+		filter["createdAt"] = createdAt
+	}
+
+	if params.Cursor != "" {
+		cursorID, err := primitive.ObjectIDFromHex(params.Cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		filter["_id"] = bson.M{"$lt": cursorID}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(params.Limit)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search orders: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	orders := make([]model.Order, 0)
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, fmt.Errorf("failed to decode orders: %w", err)
+	}
+	return orders, nil
+}
+
+// GetLatestRefundSnapshot returns the most recent refund snapshot saved for an order, or nil.
+func (r *OrderRepo) GetLatestRefundSnapshot(ctx context.Context, orderID string) (*model.Transaction, error) {
+	var snapshot model.Transaction
+	opts := options.FindOne().SetSort(bson.D{{Key: "_id", Value: -1}})
+	err := r.collectionRefund.FindOne(ctx, bson.M{"order_id": orderID}, opts).Decode(&snapshot)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find refunds: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// FindStaleOrders returns orders created before cutoff that are still awaiting a payment
+// outcome, least recently reconciled first so orders that never settle do not starve the rest.
+func (r *OrderRepo) FindStaleOrders(ctx context.Context, cutoff time.Time, limit int64) ([]model.Order, error) {
+	sort := bson.D{{Key: "lastReconciledAt", Value: 1}, {Key: "createdAt", Value: 1}}
+	return r.findOrdersCreatedBefore(ctx, model.AwaitingPaymentStatuses, cutoff, nil, sort, limit)
+}
+
+// MarkReconciled records that the reconciler checked an order with its provider.
+func (r *OrderRepo) MarkReconciled(ctx context.Context, orderID string) error {
+	update := bson.M{"$set": bson.M{"lastReconciledAt": time.Now()}}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"transactionReferenceId": orderID}, update); err != nil {
+		return fmt.Errorf("failed to mark order reconciled: %w", err)
+	}
+	return nil
+}
+
+// FindUnpaidOrders returns orders created before cutoff that the customer never paid for.
+// Orders whose expiry failed are skipped until their retry time, oldest retry first.
+func (r *OrderRepo) FindUnpaidOrders(ctx context.Context, cutoff time.Time, limit int64) ([]model.Order, error) {
+	due := bson.M{"$or": bson.A{
+		bson.M{"expiryRetryAt": bson.M{"$exists": false}},
+		bson.M{"expiryRetryAt": bson.M{"$lte": time.Now()}},
+	}}
+	sort := bson.D{{Key: "expiryRetryAt", Value: 1}, {Key: "createdAt", Value: 1}}
+	return r.findOrdersCreatedBefore(ctx, model.UnpaidStatuses, cutoff, due, sort, limit)
+}
+
+// DeferExpiry counts a failed expiry attempt and holds the order back until retryAt.
+func (r *OrderRepo) DeferExpiry(ctx context.Context, orderID string, retryAt time.Time) error {
 	update := bson.M{
-		"$set": bson.M{
-			"amount":     order.Amount,
-			"status":     order.Status,
-			"updated_at": order.UpdatedAt,
+		"$set": bson.M{"expiryRetryAt": retryAt},
+		"$inc": bson.M{"expiryAttempts": 1},
+	}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"transactionReferenceId": orderID}, update); err != nil {
+		return fmt.Errorf("failed to defer order expiry: %w", err)
+	}
+	return nil
+}
+
+func (r *OrderRepo) findOrdersCreatedBefore(ctx context.Context, statuses []model.OrderStatus, cutoff time.Time, extra bson.M, sort bson.D, limit int64) ([]model.Order, error) {
+	filter := bson.M{
+		"status":    bson.M{"$in": model.StoredStatusValues(statuses...)},
+		"createdAt": bson.M{"$lt": cutoff},
+	}
+	for key, value := range extra {
+		filter[key] = value
+	}
+	opts := options.Find().SetSort(sort).SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orders: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var orders []model.Order
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, fmt.Errorf("failed to decode orders: %w", err)
+	}
+	return orders, nil
+}
+
+// RecordCapture sets the captured amount the first time an order is captured.
+func (r *OrderRepo) RecordCapture(ctx context.Context, orderID string, amount money.Amount) error {
+	filter := bson.M{
+		"transactionReferenceId": orderID,
+		"$or": bson.A{
+			bson.M{"capturedAmount": bson.M{"$exists": false}},
+			bson.M{"capturedAmount": 0},
 		},
 	}
+	update := bson.M{"$set": bson.M{"capturedAmount": amount, "updatedAt": time.Now()}}
 
-	_, err := r.collection.UpdateOne(ctx, filter, update)
-	return err
+	if _, err := r.collection.UpdateOne(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to record capture: %w", err)
+	}
+	return nil
+}
+
+// SetCapturedAmount records the amount actually captured, replacing any value
+// recorded earlier on the assumption of a full capture.
+func (r *OrderRepo) SetCapturedAmount(ctx context.Context, orderID string, amount money.Amount) error {
+	update := bson.M{"$set": bson.M{"capturedAmount": amount, "updatedAt": time.Now()}}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"transactionReferenceId": orderID}, update); err != nil {
+		return fmt.Errorf("failed to record capture: %w", err)
+	}
+	return nil
+}
+
+// refundableStatuses are the order states that accept new refunds.
+var refundableStatuses = model.StoredStatusValues(model.OrderStatusProcessed, model.OrderStatusPartiallyRefunded)
+
+// capturedOrAmount evaluates to the order's captured amount, or its order amount
+// when no capture was recorded (orders saved before captures were tracked, or
+// moved to processed by hand).
+var capturedOrAmount = bson.M{"$cond": bson.A{
+	bson.M{"$gt": bson.A{bson.M{"$ifNull": bson.A{"$capturedAmount", 0}}, 0}},
+	"$capturedAmount",
+	"$amount",
+}}
+
+// ReserveRefund atomically reserves amount against the order's refundable balance
+// (captured minus refunded minus already reserved). Orders without a recorded
+// capture fall back to their order amount.
+func (r *OrderRepo) ReserveRefund(ctx context.Context, orderID string, amount money.Amount) (model.Order, error) {
+	filter := bson.M{
+		"transactionReferenceId": orderID,
+		"status":                 bson.M{"$in": refundableStatuses},
+		"$expr": bson.M{
+			"$lte": bson.A{
+				bson.M{"$add": bson.A{
+					bson.M{"$ifNull": bson.A{"$refundedAmount", 0}},
+					bson.M{"$ifNull": bson.A{"$refundPendingAmount", 0}},
+					amount,
+				}},
+				capturedOrAmount,
+			},
+		},
+	}
+	update := bson.M{
+		"$inc": bson.M{"refundPendingAmount": amount},
+		"$set": bson.M{"updatedAt": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var order model.Order
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&order)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return model.Order{}, ErrRefundBalanceExceeded
+		}
+		return model.Order{}, fmt.Errorf("failed to reserve refund: %w", err)
+	}
+	return order, nil
+}
+
+// SettleRefund turns a reserved amount into a refunded amount and returns the updated order.
+func (r *OrderRepo) SettleRefund(ctx context.Context, orderID string, amount money.Amount) (model.Order, error) {
+	update := bson.M{
+		"$inc": bson.M{"refundPendingAmount": -amount, "refundedAmount": amount},
+		"$set": bson.M{"updatedAt": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var order model.Order
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"transactionReferenceId": orderID}, update, opts).Decode(&order)
+	if err != nil {
+		return model.Order{}, fmt.Errorf("failed to settle refund: %w", err)
+	}
+	return order, nil
+}
+
+// ReleaseRefund returns a reserved amount to the refundable balance after a refund fails.
+func (r *OrderRepo) ReleaseRefund(ctx context.Context, orderID string, amount money.Amount) error {
+	update := bson.M{
+		"$inc": bson.M{"refundPendingAmount": -amount},
+		"$set": bson.M{"updatedAt": time.Now()},
+	}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"transactionReferenceId": orderID}, update); err != nil {
+		return fmt.Errorf("failed to release refund: %w", err)
+	}
+	return nil
+}
+
+// TransitionStatus moves an order to the given status if the state machine allows it,
+// recording the transition in statusHistory. The update is conditional on the status
+// read beforehand, so concurrent transitions cannot skip a state check.
+// Transitioning to the current status is a no-op; changed reports whether the
+// status actually moved.
+func (r *OrderRepo) TransitionStatus(ctx context.Context, referenceID string, to model.OrderStatus, actor, reason string) (order model.Order, changed bool, err error) {
+	for attempt := 0; attempt < maxTransitionAttempts; attempt++ {
+		order, err = r.GetOrderByTransactionReferenceId(ctx, referenceID)
+		if err != nil {
+			return model.Order{}, false, err
+		}
+
+		current := model.NormalizeOrderStatus(string(order.Status))
+		if current == to {
+			return order, false, nil
+		}
+		if !current.CanTransitionTo(to) {
+			return order, false, fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, current, to)
+		}
+
+		now := time.Now()
+		transition := model.OrderStatusTransition{
+			From:   current,
+			To:     to,
+			Actor:  actor,
+			Reason: reason,
+			At:     now,
+		}
+
+		// Match on the raw stored value so legacy spellings are covered too.
+		filter := bson.M{"_id": order.ID, "status": order.Status}
+		update := bson.M{
+			"$set":  bson.M{"status": to, "updatedAt": now},
+			"$push": bson.M{"statusHistory": transition},
+		}
+
+		result, err := r.collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return model.Order{}, false, fmt.Errorf("failed to update order status: %w", err)
+		}
+		if result.MatchedCount == 1 {
+			order.Status = to
+			order.UpdatedAt = now
+			order.StatusHistory = append(order.StatusHistory, transition)
+			return order, true, nil
+		}
+	}
+
+	return model.Order{}, false, fmt.Errorf("%w: %s", ErrStatusTransitionConflict, referenceID)
 }
 
 func (r *OrderRepo) SaveRefund(ctx context.Context, refund model.Transaction) error {
@@ -116,7 +432,8 @@ func (r *OrderRepo) SaveRefund(ctx context.Context, refund model.Transaction) er
 	return nil
 }
 
-func (r *OrderRepo) SaveRefundResponse(ctx context.Context, refund *dto.RefundOrderResponse) error {
+// SaveRefundResponse stores a provider's raw refund payload for audit.
+func (r *OrderRepo) SaveRefundResponse(ctx context.Context, refund interface{}) error {
 
 	_, err := r.collectionRefundResponse.InsertOne(ctx, refund)
 	if err != nil {
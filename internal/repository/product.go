@@ -2,11 +2,26 @@ package repository
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/aakritigkmit/payment-gateway/internal/dto"
 	"github.com/aakritigkmit/payment-gateway/internal/model"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrProductNotFound is returned when no product matches the lookup.
+	ErrProductNotFound = errors.New("product not found")
+	// ErrInvalidCursor is returned when a pagination cursor is not a valid ID.
+	ErrInvalidCursor = errors.New("invalid cursor")
 )
 
 type ProductRepo struct {
@@ -17,27 +32,149 @@ func NewProductRepo(db *mongo.Database) *ProductRepo {
 	return &ProductRepo{collection: db.Collection("products")}
 }
 
-func (r *ProductRepo) FindOrCreateProduct(ctx context.Context, product model.Product) error {
-	filter := bson.M{"unique_id": product.UniqueId}
+// EnsureIndexes creates the indexes used by catalog queries, including the text
+// index behind free-text search.
+func (r *ProductRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "inactive", Value: 1}, {Key: "synced_at", Value: 1}}},
+		{Keys: bson.D{{Key: "operator.country.iso_code", Value: 1}, {Key: "type", Value: 1}}},
+		{Keys: bson.D{{Key: "operator.id", Value: 1}}},
+		{Keys: bson.D{{Key: "service.id", Value: 1}, {Key: "service.subservice.id", Value: 1}}},
+		{Keys: bson.D{{Key: "prices.wholesale.unit", Value: 1}, {Key: "prices.wholesale.amount", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}},
+			Options: options.Index().SetWeights(bson.D{{Key: "name", Value: 5}, {Key: "description", Value: 1}}),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create product indexes: %w", err)
+	}
 
-	// Check if the product already exists
-	count, err := r.collection.CountDocuments(ctx, filter)
+	// Created on its own so an older database, still holding duplicates or the
+	// non-unique index, does not block the indexes above.
+	_, err = r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "unique_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
 	if err != nil {
-		return fmt.Errorf("failed to check for existing product: %w", err)
+		return fmt.Errorf("failed to create unique product index (run migration 0002_unique_product_ids): %w", err)
+	}
+	return nil
+}
+
+// ProductUpsertResult describes what UpsertProduct did with a synced product.
+type ProductUpsertResult struct {
+	Created      bool
+	Updated      bool
+	PriceChanged bool
+	// Previous is the stored product before the update, set when Updated is true.
+	Previous *model.Product
+}
+
+// UpsertProduct stores a product fetched from DT One, keyed by unique_id. Products whose
+// content hash is unchanged only have synced_at refreshed (and are reactivated if needed).
+func (r *ProductRepo) UpsertProduct(ctx context.Context, product model.Product, syncedAt time.Time) (ProductUpsertResult, error) {
+	contentHash, priceHash, err := productHashes(product)
+	if err != nil {
+		return ProductUpsertResult{}, err
+	}
+
+	filter := bson.M{"unique_id": product.UniqueId}
+
+	var existing model.Product
+	err = r.collection.FindOne(ctx, filter).Decode(&existing)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return ProductUpsertResult{}, fmt.Errorf("failed to load product %d: %w", product.UniqueId, err)
+	}
+	found := err == nil
+
+	if found && existing.ContentHash == contentHash {
+		update := bson.M{
+			"$set":   bson.M{"synced_at": syncedAt},
+			"$unset": bson.M{"inactive": "", "deactivated_at": ""},
+		}
+		if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": existing.ID}, update); err != nil {
+			return ProductUpsertResult{}, fmt.Errorf("failed to touch product %d: %w", product.UniqueId, err)
+		}
+		return ProductUpsertResult{}, nil
+	}
+
+	product.ID = primitive.NilObjectID
+	product.ContentHash = contentHash
+	product.PriceHash = priceHash
+	product.SyncedAt = &syncedAt
+	product.Inactive = false
+	product.DeactivatedAt = nil
+
+	// The replacement has no _id, so an existing document keeps its own.
+	opts := options.Replace().SetUpsert(true)
+	if _, err := r.collection.ReplaceOne(ctx, filter, product, opts); err != nil {
+		// A concurrent sync inserted the product first; retry against its copy.
+		if !found && mongo.IsDuplicateKeyError(err) {
+			return r.UpsertProduct(ctx, product, syncedAt)
+		}
+		return ProductUpsertResult{}, fmt.Errorf("failed to upsert product %d: %w", product.UniqueId, err)
 	}
 
-	// If it exists, skip insert
-	if count > 0 {
-		return nil
+	if !found {
+		return ProductUpsertResult{Created: true, PriceChanged: true}, nil
+	}
+	// Products stored before price hashing have no PriceHash and count as a price change
+	// once, which gives them a baseline history entry.
+	return ProductUpsertResult{
+		Updated:      true,
+		PriceChanged: existing.PriceHash != priceHash,
+		Previous:     &existing,
+	}, nil
+}
+
+// DeactivateMissing marks products matching scope inactive if the sync that started at
+// syncedAt did not see them.
+func (r *ProductRepo) DeactivateMissing(ctx context.Context, scope bson.M, syncedAt time.Time) (int64, error) {
+	filter := bson.M{
+		"inactive": bson.M{"$ne": true},
+		"$or": bson.A{
+			bson.M{"synced_at": bson.M{"$lt": syncedAt}},
+			bson.M{"synced_at": bson.M{"$exists": false}},
+		},
+	}
+	for key, value := range scope {
+		filter[key] = value
 	}
 
-	// If not, insert the new product
-	_, err = r.collection.InsertOne(ctx, product)
+	update := bson.M{"$set": bson.M{"inactive": true, "deactivated_at": time.Now()}}
+	res, err := r.collection.UpdateMany(ctx, filter, update)
 	if err != nil {
-		return fmt.Errorf("failed to insert product: %w", err)
+		return 0, fmt.Errorf("failed to deactivate missing products: %w", err)
 	}
+	return res.ModifiedCount, nil
+}
 
-	return nil
+// productHashes returns hashes of the DT One content of a product and of its prices and
+// rates alone. Map keys are sorted by encoding/json, so the hashes are stable.
+func productHashes(product model.Product) (string, string, error) {
+	product.ID = primitive.NilObjectID
+	product.ContentHash = ""
+	product.PriceHash = ""
+	product.SyncedAt = nil
+	product.Inactive = false
+	product.DeactivatedAt = nil
+
+	content, err := json.Marshal(product)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to hash product %d: %w", product.UniqueId, err)
+	}
+	prices, err := json.Marshal(struct {
+		Prices model.Prices `json:"prices"`
+		Rates  model.Rates  `json:"rates"`
+	}{product.Prices, product.Rates})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to hash product %d prices: %w", product.UniqueId, err)
+	}
+
+	contentSum := sha256.Sum256(content)
+	priceSum := sha256.Sum256(prices)
+	return hex.EncodeToString(contentSum[:]), hex.EncodeToString(priceSum[:]), nil
 }
 
 func (r *ProductRepo) GetProductsByUniqueIDs(ctx context.Context, ids []int) ([]model.Product, error) {
@@ -55,3 +192,101 @@ func (r *ProductRepo) GetProductsByUniqueIDs(ctx context.Context, ids []int) ([]
 	}
 	return products, nil
 }
+
+// FindActiveProducts returns every product still offered by DT One.
+func (r *ProductRepo) FindActiveProducts(ctx context.Context) ([]model.Product, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "unique_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"inactive": bson.M{"$ne": true}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch active products: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var products []model.Product
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, fmt.Errorf("failed to decode active products: %w", err)
+	}
+	return products, nil
+}
+
+// GetProductByUniqueID looks a product up by its DT One product ID.
+func (r *ProductRepo) GetProductByUniqueID(ctx context.Context, id int) (model.Product, error) {
+	return r.findOne(ctx, bson.M{"unique_id": id})
+}
+
+func (r *ProductRepo) GetProductByObjectID(ctx context.Context, id primitive.ObjectID) (model.Product, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *ProductRepo) findOne(ctx context.Context, filter bson.M) (model.Product, error) {
+	var product model.Product
+	if err := r.collection.FindOne(ctx, filter).Decode(&product); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return model.Product{}, ErrProductNotFound
+		}
+		return model.Product{}, err
+	}
+	return product, nil
+}
+
+// SearchProducts returns one page of the catalog in _id order.
+func (r *ProductRepo) SearchProducts(ctx context.Context, params dto.ProductSearchParams) ([]model.Product, error) {
+	filter := bson.M{}
+	if !params.IncludeInactive {
+		filter["inactive"] = bson.M{"$ne": true}
+	}
+	if params.CountryISOCode != "" {
+		filter["operator.country.iso_code"] = params.CountryISOCode
+	}
+	if params.OperatorID != 0 {
+		filter["operator.id"] = params.OperatorID
+	}
+	if params.ServiceID != 0 {
+		filter["service.id"] = params.ServiceID
+	}
+	if params.SubServiceID != 0 {
+		filter["service.subservice.id"] = params.SubServiceID
+	}
+	if params.Type != "" {
+		filter["type"] = params.Type
+	}
+	if params.Currency != "" {
+		filter["prices.wholesale.unit"] = params.Currency
+	}
+	if params.Query != "" {
+		filter["$text"] = bson.M{"$search": params.Query}
+	}
+
+	price := bson.M{}
+	if params.MinPrice != nil {
+		price["$gte"] = *params.MinPrice
+	}
+	if params.MaxPrice != nil {
+		price["$lte"] = *params.MaxPrice
+	}
+	if len(price) > 0 {
+		filter["prices.wholesale.amount"] = price
+	}
+
+	if params.Cursor != "" {
+		cursorID, err := primitive.ObjectIDFromHex(params.Cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		filter["_id"] = bson.M{"$gt": cursorID}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(params.Limit)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search products: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	products := make([]model.Product, 0)
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, fmt.Errorf("failed to decode products: %w", err)
+	}
+	return products, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,6 +11,8 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+var ErrProductOrderNotFound = errors.New("product order not found")
+
 type ProductOrderRepo struct {
 	collection               *mongo.Collection
 	productPinDumpcollection *mongo.Collection
@@ -31,6 +34,26 @@ func (r *ProductOrderRepo) SaveProductPins(ctx context.Context, pin model.Produc
 	return nil
 }
 
+func (r *ProductOrderRepo) GetProductOrder(ctx context.Context, orderID string) (model.ProductPin, error) {
+	var order model.ProductPin
+	if err := r.collection.FindOne(ctx, bson.M{"orderID": orderID}).Decode(&order); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return model.ProductPin{}, ErrProductOrderNotFound
+		}
+		return model.ProductPin{}, fmt.Errorf("failed to find product order %s: %w", orderID, err)
+	}
+	return order, nil
+}
+
+// MarkFinished records that bulk processing of the order has stopped.
+func (r *ProductOrderRepo) MarkFinished(ctx context.Context, orderID string, at time.Time) error {
+	update := bson.M{"$set": bson.M{"finished_at": at, "updated_at": at}}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"orderID": orderID}, update); err != nil {
+		return fmt.Errorf("failed to mark product order %s finished: %w", orderID, err)
+	}
+	return nil
+}
+
 func (r *ProductOrderRepo) UpdateProductPinsByOrderID(ctx context.Context, orderId string, update bson.M) error {
 	filter := bson.M{"orderID": orderId}
 	_, err := r.collection.UpdateOne(ctx, filter, update)
@@ -62,6 +85,26 @@ func (r *ProductOrderRepo) UpdateProductOrderWithPins(ctx context.Context, order
 	return err
 }
 
+// AddProductPins adds pins to an order, skipping transactions that already have a pin
+// there, so pins from DT One callbacks and from polling can arrive in any order.
+func (r *ProductOrderRepo) AddProductPins(ctx context.Context, orderID string, pins []model.ProductPinItem) error {
+	pipeline := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"productPins": bson.M{"$concatArrays": bson.A{
+			bson.M{"$ifNull": bson.A{"$productPins", bson.A{}}},
+			bson.M{"$filter": bson.M{
+				"input": bson.M{"$literal": pins},
+				"cond":  bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$$this.external_id", bson.M{"$ifNull": bson.A{"$productPins.external_id", bson.A{}}}}}}},
+			}},
+		}},
+		"updated_at": time.Now(),
+	}}}}
+
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"orderID": orderID}, pipeline); err != nil {
+		return fmt.Errorf("failed to add product pins for orderID %s: %w", orderID, err)
+	}
+	return nil
+}
+
 func (r *ProductOrderRepo) UpdateProductPins(ctx context.Context, orderID string, pins []model.ProductPinItem) error {
 
 	filter := bson.M{"orderID": orderID}
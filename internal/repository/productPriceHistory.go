@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ProductPriceHistoryRepo struct {
+	collection *mongo.Collection
+}
+
+func NewProductPriceHistoryRepo(db *mongo.Database) *ProductPriceHistoryRepo {
+	return &ProductPriceHistoryRepo{collection: db.Collection("product_price_history")}
+}
+
+func (r *ProductPriceHistoryRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "changed_at", Value: -1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create product price history indexes: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductPriceHistoryRepo) Insert(ctx context.Context, entry model.ProductPriceHistory) error {
+	if _, err := r.collection.InsertOne(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save price history for product %d: %w", entry.ProductID, err)
+	}
+	return nil
+}
+
+// FindByProduct returns a product's price changes, newest first.
+func (r *ProductPriceHistoryRepo) FindByProduct(ctx context.Context, productID int, limit int64) ([]model.ProductPriceHistory, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "changed_at", Value: -1}}).SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, bson.M{"product_id": productID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find price history: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	history := make([]model.ProductPriceHistory, 0)
+	if err := cursor.All(ctx, &history); err != nil {
+		return nil, fmt.Errorf("failed to decode price history: %w", err)
+	}
+	return history, nil
+}
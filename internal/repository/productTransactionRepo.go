@@ -2,14 +2,18 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/aakritigkmit/payment-gateway/internal/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var ErrProductTransactionNotFound = errors.New("product transaction not found")
+
 type ProductTransactionRepo struct {
 	collection *mongo.Collection
 }
@@ -20,8 +24,21 @@ func NewProductTransactionRepo(db *mongo.Database) *ProductTransactionRepo {
 	}
 }
 
+func (r *ProductTransactionRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "external_id", Value: 1}}},
+		{Keys: bson.D{{Key: "order_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "status.class.id", Value: 1}, {Key: "auto_confirm", Value: 1}, {Key: "confirmation_expiration_date", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create product transaction indexes: %w", err)
+	}
+	return nil
+}
+
 // SaveProductTransaction inserts a new product transaction into the database
 func (r *ProductTransactionRepo) SaveProductTransaction(ctx context.Context, tx model.ProductTransaction) error {
+	tx.StatusRank = tx.Status.Rank()
 	_, err := r.collection.InsertOne(ctx, tx)
 	if err != nil {
 		return fmt.Errorf("failed to save product transaction: %w", err)
@@ -45,14 +62,17 @@ func (r *ProductTransactionRepo) FindProductTransactionByExternalID(ctx context.
 	return &tx, nil
 }
 
-func (r *ProductTransactionRepo) UpdateProductTransaction(ctx context.Context, externalID string, updatedData model.ProductTransaction) error {
+// UpdateProductTransaction stores DT One's latest view of a transaction. Updates that
+// would move the transaction to an earlier status, or to a different final status, are
+// skipped and reported as not applied; repeating the current status is applied again.
+func (r *ProductTransactionRepo) UpdateProductTransaction(ctx context.Context, externalID string, updatedData model.ProductTransaction) (bool, error) {
 	filter := bson.M{"external_id": externalID}
 
 	// Step 1: Fetch the existing document to get created_at
 	var existing model.ProductTransaction
 	err := r.collection.FindOne(ctx, filter).Decode(&existing)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	// Step 2: Calculate updation_time
@@ -60,6 +80,7 @@ func (r *ProductTransactionRepo) UpdateProductTransaction(ctx context.Context, e
 	updationTime := now.Sub(existing.CreatedAt) // assuming CreatedAt is of type time.Time
 
 	// Step 3: Add updation_time to the update object
+	rank := updatedData.Status.Rank()
 	update := bson.M{
 		"$set": bson.M{
 			"benefits":                     updatedData.Benefits,
@@ -76,11 +97,63 @@ func (r *ProductTransactionRepo) UpdateProductTransaction(ctx context.Context, e
 			"promotions":                   updatedData.Promotions,
 			"rates":                        updatedData.Rates,
 			"status":                       updatedData.Status,
+			"status_rank":                  rank,
 			"updated_at":                   now,
 			"updation_time":                updationTime.String(),
 		},
 	}
 
-	_, err = r.collection.UpdateOne(ctx, filter, update)
-	return err
+	// Step 4: Only move the status forward
+	filter["$or"] = bson.A{
+		bson.M{"status_rank": bson.M{"$exists": false}},
+		bson.M{"status_rank": bson.M{"$lt": rank}},
+		bson.M{"status_rank": rank, "status.id": updatedData.Status.ID},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// FindByOrderID returns the transactions placed by a bulk order, oldest first.
+func (r *ProductTransactionRepo) FindByOrderID(ctx context.Context, orderID string) ([]model.ProductTransaction, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"order_id": orderID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find product transactions for order %s: %w", orderID, err)
+	}
+	defer cursor.Close(ctx)
+
+	txs := make([]model.ProductTransaction, 0)
+	if err := cursor.All(ctx, &txs); err != nil {
+		return nil, fmt.Errorf("failed to decode product transactions: %w", err)
+	}
+	return txs, nil
+}
+
+// FindExpiringInStatusClass returns up to limit transactions created with auto_confirm
+// false, in the given DT One status class, whose confirmation expires before the given
+// time, soonest first. Transactions without an expiration date are skipped.
+func (r *ProductTransactionRepo) FindExpiringInStatusClass(ctx context.Context, statusClass int, before time.Time, limit int64) ([]model.ProductTransaction, error) {
+	filter := bson.M{
+		"status.class.id":              statusClass,
+		"auto_confirm":                 false,
+		"confirmation_expiration_date": bson.M{"$gt": time.Time{}, "$lt": before},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "confirmation_expiration_date", Value: 1}}).SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find expiring product transactions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	txs := make([]model.ProductTransaction, 0)
+	if err := cursor.All(ctx, &txs); err != nil {
+		return nil, fmt.Errorf("failed to decode product transactions: %w", err)
+	}
+	return txs, nil
 }
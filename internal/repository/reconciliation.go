@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ReconciliationRepo struct {
+	collection *mongo.Collection
+}
+
+func NewReconciliationRepo(db *mongo.Database) *ReconciliationRepo {
+	return &ReconciliationRepo{collection: db.Collection("reconciliation_runs")}
+}
+
+func (r *ReconciliationRepo) SaveRun(ctx context.Context, run model.ReconciliationRun) error {
+	_, err := r.collection.InsertOne(ctx, run)
+	if err != nil {
+		return fmt.Errorf("failed to save reconciliation run: %w", err)
+	}
+	return nil
+}
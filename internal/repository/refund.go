@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrDuplicateRefundReference is returned when a merchant refund reference has already been used.
+	ErrDuplicateRefundReference = errors.New("merchant refund reference already used")
+	// ErrRefundEntryNotFound is returned when no ledger entry matches the given ID.
+	ErrRefundEntryNotFound = errors.New("refund not found")
+)
+
+type RefundRepo struct {
+	collection *mongo.Collection
+}
+
+func NewRefundRepo(db *mongo.Database) *RefundRepo {
+	return &RefundRepo{collection: db.Collection("refund_ledger")}
+}
+
+func (r *RefundRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "order_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "updated_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "last_checked_at", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "merchant_refund_reference", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create refund ledger indexes: %w", err)
+	}
+	return nil
+}
+
+func (r *RefundRepo) CreateEntry(ctx context.Context, entry model.RefundEntry) (model.RefundEntry, error) {
+	result, err := r.collection.InsertOne(ctx, entry)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return model.RefundEntry{}, ErrDuplicateRefundReference
+		}
+		return model.RefundEntry{}, fmt.Errorf("failed to create refund entry: %w", err)
+	}
+	entry.ID = result.InsertedID.(primitive.ObjectID)
+	return entry, nil
+}
+
+// TransitionEntry moves an entry from one of the given statuses to the next one,
+// applying extra fields in the same update. It reports whether the entry matched,
+// so a refund is settled or released exactly once.
+func (r *RefundRepo) TransitionEntry(ctx context.Context, id primitive.ObjectID, from []model.RefundStatus, to model.RefundStatus, fields bson.M) (bool, error) {
+	set := bson.M{"status": to, "updated_at": time.Now()}
+	for k, v := range fields {
+		set[k] = v
+	}
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": bson.M{"$in": from}},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update refund entry: %w", err)
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *RefundRepo) GetEntriesByOrderID(ctx context.Context, orderID string) ([]model.RefundEntry, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"order_id": orderID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch refund entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	entries := make([]model.RefundEntry, 0)
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode refund entries: %w", err)
+	}
+	return entries, nil
+}
+
+// FindOpenEntries returns refunds still waiting on the provider, least recently checked
+// first. Requested entries are only included once they are older than requestedBefore,
+// so refunds still being submitted are left alone.
+func (r *RefundRepo) FindOpenEntries(ctx context.Context, requestedBefore time.Time, limit int64) ([]model.RefundEntry, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": model.RefundStatusPending},
+		bson.M{"status": model.RefundStatusRequested, "created_at": bson.M{"$lt": requestedBefore}},
+	}}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "last_checked_at", Value: 1}, {Key: "created_at", Value: 1}}).
+		SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query open refund entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	entries := make([]model.RefundEntry, 0)
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode open refund entries: %w", err)
+	}
+	return entries, nil
+}
+
+// GetEntry returns the ledger entry with the given ID.
+func (r *RefundRepo) GetEntry(ctx context.Context, id primitive.ObjectID) (model.RefundEntry, error) {
+	var entry model.RefundEntry
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&entry); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return model.RefundEntry{}, ErrRefundEntryNotFound
+		}
+		return model.RefundEntry{}, fmt.Errorf("failed to fetch refund entry: %w", err)
+	}
+	return entry, nil
+}
+
+// FlagForReview marks a requested entry as needing an operator's attention. It reports
+// whether the entry was flagged just now.
+func (r *RefundRepo) FlagForReview(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": model.RefundStatusRequested, "needs_review": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"needs_review": true, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to flag refund entry for review: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// MarkChecked records that the provider was asked about an entry.
+func (r *RefundRepo) MarkChecked(ctx context.Context, id primitive.ObjectID) error {
+	update := bson.M{
+		"$set": bson.M{"last_checked_at": time.Now()},
+		"$inc": bson.M{"checks": 1},
+	}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+		return fmt.Errorf("failed to mark refund entry checked: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrSyncProfileNotFound is returned when no sync profile matches the given ID.
+	ErrSyncProfileNotFound = errors.New("sync profile not found")
+	// ErrDuplicateSyncProfile is returned when a profile with the same filters already exists.
+	ErrDuplicateSyncProfile = errors.New("a sync profile with these filters already exists")
+)
+
+type SyncProfileRepo struct {
+	collection *mongo.Collection
+}
+
+func NewSyncProfileRepo(db *mongo.Database) *SyncProfileRepo {
+	return &SyncProfileRepo{collection: db.Collection("sync_profiles")}
+}
+
+// EnsureIndexes makes each combination of filters unique, so two profiles never sync
+// the same slice of the catalog.
+func (r *SyncProfileRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "service_id", Value: 1},
+			{Key: "type", Value: 1},
+			{Key: "country_iso_code", Value: 1},
+			{Key: "operator_id", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create sync profile indexes: %w", err)
+	}
+	return nil
+}
+
+func (r *SyncProfileRepo) Create(ctx context.Context, profile model.SyncProfile) (model.SyncProfile, error) {
+	result, err := r.collection.InsertOne(ctx, profile)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return model.SyncProfile{}, ErrDuplicateSyncProfile
+		}
+		return model.SyncProfile{}, fmt.Errorf("failed to create sync profile: %w", err)
+	}
+	profile.ID = result.InsertedID.(primitive.ObjectID)
+	return profile, nil
+}
+
+func (r *SyncProfileRepo) Get(ctx context.Context, id primitive.ObjectID) (model.SyncProfile, error) {
+	var profile model.SyncProfile
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&profile)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return model.SyncProfile{}, ErrSyncProfileNotFound
+		}
+		return model.SyncProfile{}, fmt.Errorf("failed to fetch sync profile: %w", err)
+	}
+	return profile, nil
+}
+
+func (r *SyncProfileRepo) List(ctx context.Context) ([]model.SyncProfile, error) {
+	return r.find(ctx, bson.M{})
+}
+
+// FindEnabled returns the profiles a full sync runs.
+func (r *SyncProfileRepo) FindEnabled(ctx context.Context) ([]model.SyncProfile, error) {
+	return r.find(ctx, bson.M{"enabled": true})
+}
+
+// FindByIDs returns the requested profiles, failing if any of them does not exist.
+func (r *SyncProfileRepo) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]model.SyncProfile, error) {
+	profiles, err := r.find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	if len(profiles) != len(ids) {
+		found := make(map[primitive.ObjectID]bool, len(profiles))
+		for _, profile := range profiles {
+			found[profile.ID] = true
+		}
+		for _, id := range ids {
+			if !found[id] {
+				return nil, fmt.Errorf("%w: %s", ErrSyncProfileNotFound, id.Hex())
+			}
+		}
+	}
+	return profiles, nil
+}
+
+func (r *SyncProfileRepo) find(ctx context.Context, filter bson.M) ([]model.SyncProfile, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sync profiles: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	profiles := []model.SyncProfile{}
+	if err := cursor.All(ctx, &profiles); err != nil {
+		return nil, fmt.Errorf("failed to decode sync profiles: %w", err)
+	}
+	return profiles, nil
+}
+
+// Update applies the given fields and returns the updated profile.
+func (r *SyncProfileRepo) Update(ctx context.Context, id primitive.ObjectID, fields bson.M) (model.SyncProfile, error) {
+	set := bson.M{"updated_at": time.Now()}
+	for k, v := range fields {
+		set[k] = v
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var profile model.SyncProfile
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{"$set": set}, opts).Decode(&profile)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return model.SyncProfile{}, ErrSyncProfileNotFound
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			return model.SyncProfile{}, ErrDuplicateSyncProfile
+		}
+		return model.SyncProfile{}, fmt.Errorf("failed to update sync profile: %w", err)
+	}
+	return profile, nil
+}
+
+// MarkSynced records a completed sync of the profile.
+func (r *SyncProfileRepo) MarkSynced(ctx context.Context, id primitive.ObjectID, at time.Time, count int) error {
+	update := bson.M{"$set": bson.M{"last_synced_at": at, "last_sync_count": count}}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+		return fmt.Errorf("failed to mark sync profile %s synced: %w", id.Hex(), err)
+	}
+	return nil
+}
+
+func (r *SyncProfileRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete sync profile: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrSyncProfileNotFound
+	}
+	return nil
+}
@@ -2,12 +2,19 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/aakritigkmit/payment-gateway/internal/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+const (
+	customerEmailField  = "purchasedetails.customer.email_id"
+	customerMobileField = "purchasedetails.customer.mobile_number"
+)
+
 type TransactionRepo struct {
 	collection *mongo.Collection
 }
@@ -30,6 +37,15 @@ func (r *TransactionRepo) UpdateTransactionByOrderID(ctx context.Context, orderI
 	return err
 }
 
+// UpdateTransactionStatus sets the provider-style status (e.g. "EXPIRED") of an order's transaction.
+func (r *TransactionRepo) UpdateTransactionStatus(ctx context.Context, orderID, status string) error {
+	update := bson.M{"$set": bson.M{"status": status, "updated_at": time.Now()}}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"order_id": orderID}, update); err != nil {
+		return fmt.Errorf("failed to update transaction status: %w", err)
+	}
+	return nil
+}
+
 func (r *TransactionRepo) GetTransactionByPineOrderID(ctx context.Context, pineOrderID string) (model.Transaction, error) {
 
 	var tx model.Transaction
@@ -40,3 +56,54 @@ func (r *TransactionRepo) GetTransactionByPineOrderID(ctx context.Context, pineO
 
 	return tx, nil
 }
+
+// EnsureIndexes creates the indexes used by order lookups and customer search.
+func (r *TransactionRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "order_id", Value: 1}}},
+		{Keys: bson.D{{Key: customerEmailField, Value: 1}}},
+		{Keys: bson.D{{Key: customerMobileField, Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create transaction indexes: %w", err)
+	}
+	return nil
+}
+
+// FindOrderIDsByCustomer returns the provider order IDs of transactions matching the customer's email and/or mobile.
+func (r *TransactionRepo) FindOrderIDsByCustomer(ctx context.Context, email, mobile string) ([]string, error) {
+	filter := bson.M{}
+	if email != "" {
+		filter[customerEmailField] = email
+	}
+	if mobile != "" {
+		filter[customerMobileField] = mobile
+	}
+
+	values, err := r.collection.Distinct(ctx, "order_id", filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search transactions: %w", err)
+	}
+
+	orderIDs := make([]string, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(string); ok {
+			orderIDs = append(orderIDs, id)
+		}
+	}
+	return orderIDs, nil
+}
+
+func (r *TransactionRepo) GetTransactionsByPineOrderIDs(ctx context.Context, pineOrderIDs []string) ([]model.Transaction, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"order_id": bson.M{"$in": pineOrderIDs}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var txs []model.Transaction
+	if err := cursor.All(ctx, &txs); err != nil {
+		return nil, fmt.Errorf("failed to decode transactions: %w", err)
+	}
+	return txs, nil
+}
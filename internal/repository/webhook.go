@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrWebhookEndpointNotFound is returned when no endpoint matches the given ID.
+	ErrWebhookEndpointNotFound = errors.New("webhook endpoint not found")
+	// ErrWebhookDeliveryNotFound is returned when no delivery matches the given ID.
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+type WebhookRepo struct {
+	endpoints  *mongo.Collection
+	deliveries *mongo.Collection
+}
+
+func NewWebhookRepo(db *mongo.Database) *WebhookRepo {
+	return &WebhookRepo{
+		endpoints:  db.Collection("webhook_endpoints"),
+		deliveries: db.Collection("webhook_deliveries"),
+	}
+}
+
+// EnsureIndexes creates the indexes used by event fan-out, the dispatcher and the
+// delivery listing.
+func (r *WebhookRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.endpoints.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "created_by", Value: 1}, {Key: "active", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create webhook endpoint indexes: %w", err)
+	}
+
+	_, err = r.deliveries.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "created_by", Value: 1}, {Key: "endpoint_id", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "created_by", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "event_id", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery indexes: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepo) CreateEndpoint(ctx context.Context, endpoint model.WebhookEndpoint) (model.WebhookEndpoint, error) {
+	result, err := r.endpoints.InsertOne(ctx, endpoint)
+	if err != nil {
+		return model.WebhookEndpoint{}, fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+	endpoint.ID = result.InsertedID.(primitive.ObjectID)
+	return endpoint, nil
+}
+
+func (r *WebhookRepo) GetEndpoint(ctx context.Context, id primitive.ObjectID) (model.WebhookEndpoint, error) {
+	var endpoint model.WebhookEndpoint
+	err := r.endpoints.FindOne(ctx, bson.M{"_id": id}).Decode(&endpoint)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return model.WebhookEndpoint{}, ErrWebhookEndpointNotFound
+		}
+		return model.WebhookEndpoint{}, fmt.Errorf("failed to fetch webhook endpoint: %w", err)
+	}
+	return endpoint, nil
+}
+
+// ListEndpoints returns the endpoints created by owner.
+func (r *WebhookRepo) ListEndpoints(ctx context.Context, owner string) ([]model.WebhookEndpoint, error) {
+	return r.findEndpoints(ctx, bson.M{"created_by": owner})
+}
+
+// FindActiveEndpoints returns owner's endpoints that are currently enabled.
+func (r *WebhookRepo) FindActiveEndpoints(ctx context.Context, owner string) ([]model.WebhookEndpoint, error) {
+	return r.findEndpoints(ctx, bson.M{"created_by": owner, "active": true})
+}
+
+func (r *WebhookRepo) findEndpoints(ctx context.Context, filter bson.M) ([]model.WebhookEndpoint, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.endpoints.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook endpoints: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	endpoints := []model.WebhookEndpoint{}
+	if err := cursor.All(ctx, &endpoints); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook endpoints: %w", err)
+	}
+	return endpoints, nil
+}
+
+// UpdateEndpoint applies the given fields to one of owner's endpoints and returns the
+// updated endpoint.
+func (r *WebhookRepo) UpdateEndpoint(ctx context.Context, id primitive.ObjectID, owner string, fields bson.M) (model.WebhookEndpoint, error) {
+	set := bson.M{"updated_at": time.Now()}
+	for k, v := range fields {
+		set[k] = v
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var endpoint model.WebhookEndpoint
+	err := r.endpoints.FindOneAndUpdate(ctx, bson.M{"_id": id, "created_by": owner}, bson.M{"$set": set}, opts).Decode(&endpoint)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return model.WebhookEndpoint{}, ErrWebhookEndpointNotFound
+		}
+		return model.WebhookEndpoint{}, fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+	return endpoint, nil
+}
+
+// DeleteEndpoint deletes one of owner's endpoints.
+func (r *WebhookRepo) DeleteEndpoint(ctx context.Context, id primitive.ObjectID, owner string) error {
+	result, err := r.endpoints.DeleteOne(ctx, bson.M{"_id": id, "created_by": owner})
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrWebhookEndpointNotFound
+	}
+	return nil
+}
+
+func (r *WebhookRepo) CreateDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(deliveries))
+	for i, d := range deliveries {
+		docs[i] = d
+	}
+	if _, err := r.deliveries.InsertMany(ctx, docs); err != nil {
+		return fmt.Errorf("failed to create webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// GetDelivery returns one of owner's deliveries.
+func (r *WebhookRepo) GetDelivery(ctx context.Context, id primitive.ObjectID, owner string) (model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := r.deliveries.FindOne(ctx, bson.M{"_id": id, "created_by": owner}).Decode(&delivery)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return model.WebhookDelivery{}, ErrWebhookDeliveryNotFound
+		}
+		return model.WebhookDelivery{}, fmt.Errorf("failed to fetch webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// ListDeliveries returns owner's deliveries newest first. Empty filter values are
+// ignored; cursor is the hex _id of the last delivery on the previous page.
+func (r *WebhookRepo) ListDeliveries(ctx context.Context, owner, endpointID, status, eventType, cursor string, limit int64) ([]model.WebhookDelivery, error) {
+	filter := bson.M{"created_by": owner}
+	if endpointID != "" {
+		id, err := primitive.ObjectIDFromHex(endpointID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid endpoint id: %w", err)
+		}
+		filter["endpoint_id"] = id
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+	if eventType != "" {
+		filter["event_type"] = eventType
+	}
+	if cursor != "" {
+		last, err := primitive.ObjectIDFromHex(cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		filter["_id"] = bson.M{"$lt": last}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(limit)
+	cur, err := r.deliveries.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook deliveries: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	deliveries := []model.WebhookDelivery{}
+	if err := cur.All(ctx, &deliveries); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// ClaimDueDelivery picks one pending delivery whose next attempt is due and pushes
+// its next attempt out by lease, so concurrent dispatchers do not send it twice.
+// It returns nil when nothing is due.
+func (r *WebhookRepo) ClaimDueDelivery(ctx context.Context, now time.Time, lease time.Duration) (*model.WebhookDelivery, error) {
+	filter := bson.M{
+		"status":          model.WebhookDeliveryPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease), "updated_at": now}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var delivery model.WebhookDelivery
+	err := r.deliveries.FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim webhook delivery: %w", err)
+	}
+	return &delivery, nil
+}
+
+// RecordAttempt stores the outcome of one delivery attempt.
+func (r *WebhookRepo) RecordAttempt(ctx context.Context, delivery model.WebhookDelivery) error {
+	update := bson.M{"$set": bson.M{
+		"status":           delivery.Status,
+		"attempts":         delivery.Attempts,
+		"next_attempt_at":  delivery.NextAttemptAt,
+		"last_attempt_at":  delivery.LastAttemptAt,
+		"last_status_code": delivery.LastStatusCode,
+		"last_error":       delivery.LastError,
+		"updated_at":       time.Now(),
+	}}
+	if _, err := r.deliveries.UpdateOne(ctx, bson.M{"_id": delivery.ID}, update); err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+	return nil
+}
+
+// RequeueDelivery makes one of owner's deliveries due immediately, restarting its
+// attempt count.
+func (r *WebhookRepo) RequeueDelivery(ctx context.Context, id primitive.ObjectID, owner string) (model.WebhookDelivery, error) {
+	now := time.Now()
+	update := bson.M{"$set": bson.M{
+		"status":          model.WebhookDeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": now,
+		"updated_at":      now,
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var delivery model.WebhookDelivery
+	err := r.deliveries.FindOneAndUpdate(ctx, bson.M{"_id": id, "created_by": owner}, update, opts).Decode(&delivery)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return model.WebhookDelivery{}, ErrWebhookDeliveryNotFound
+		}
+		return model.WebhookDelivery{}, fmt.Errorf("failed to requeue webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
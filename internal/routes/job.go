@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"context"
+	"log"
+
+	"github.com/aakritigkmit/payment-gateway/internal/handlers"
+	middlewares "github.com/aakritigkmit/payment-gateway/internal/middleware"
+	"github.com/aakritigkmit/payment-gateway/internal/repository"
+	"github.com/aakritigkmit/payment-gateway/internal/services"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetupJobRoutes(r chi.Router, db *mongo.Database) {
+	jobRepo := repository.NewJobRepo(db)
+	jobHandler := handlers.NewJobHandler(services.NewJobService(jobRepo, repository.NewBackgroundJobRepo(db)))
+
+	if err := jobRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to ensure job run indexes: %v", err)
+	}
+
+	r.Use(middlewares.AuthMiddleware)
+
+	r.Get("/", jobHandler.ListJobs)
+	r.Get("/runs", jobHandler.ListRuns)
+	r.Get("/runs/{id}", jobHandler.GetRun)
+	r.Get("/{id}", jobHandler.GetJob)
+	r.Delete("/{id}", jobHandler.CancelJob)
+}
@@ -1,8 +1,13 @@
 package routes
 
 import (
+	"context"
+	"log"
+
+	"github.com/aakritigkmit/payment-gateway/internal/config"
 	"github.com/aakritigkmit/payment-gateway/internal/handlers"
 	middlewares "github.com/aakritigkmit/payment-gateway/internal/middleware"
+	"github.com/aakritigkmit/payment-gateway/internal/providers"
 	"github.com/aakritigkmit/payment-gateway/internal/repository"
 	"github.com/aakritigkmit/payment-gateway/internal/services"
 	"github.com/go-chi/chi/v5"
@@ -12,12 +17,39 @@ import (
 func SetupOrderRoutes(r chi.Router, db *mongo.Database) {
 	orderRepo := repository.NewOrderRepo(db)
 	transactionRepo := repository.NewTransactionRepo(db)
-	orderService := services.NewOrderService(orderRepo, transactionRepo)
-	orderHandler := handlers.NewOrderHandler(orderService)
+	refundRepo := repository.NewRefundRepo(db)
+	providerRegistry := providers.NewRegistry(config.GetConfig())
+	webhookService := services.NewWebhookService(repository.NewWebhookRepo(db), config.GetConfig())
+	orderService := services.NewOrderService(orderRepo, transactionRepo, refundRepo, providerRegistry, webhookService)
+	callbackVerifier := services.NewCallbackVerifier(repository.NewCallbackRepo(db), config.GetConfig())
+	orderHandler := handlers.NewOrderHandler(orderService, callbackVerifier)
+
+	if err := orderRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to ensure order indexes: %v", err)
+	}
+	if err := transactionRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to ensure transaction indexes: %v", err)
+	}
+	if err := refundRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to ensure refund ledger indexes: %v", err)
+	}
+
+	idempotencyRepo := repository.NewIdempotencyRepo(db, config.GetConfig().IdempotencyKeyTTL)
+	if err := idempotencyRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to ensure idempotency indexes: %v", err)
+	}
+	idempotent := middlewares.Idempotency(idempotencyRepo)
 
 	// r.Use(middlewares.AuthMiddleware) // Apply auth middleware
 
-	r.With(middlewares.AuthMiddleware).Post("/place", orderHandler.PlaceOrder)
+	r.With(middlewares.AuthMiddleware, idempotent).Post("/place", orderHandler.PlaceOrder)
 	r.Post("/callback/order-status", orderHandler.HandleCallback)
-	r.With(middlewares.AuthMiddleware).Post("/refund", orderHandler.RefundOrder)
+	r.With(middlewares.AuthMiddleware, idempotent).Post("/refund", orderHandler.RefundOrder)
+	r.With(middlewares.AuthMiddleware).Get("/", orderHandler.ListOrders)
+	r.With(middlewares.AuthMiddleware).Get("/{id}", orderHandler.GetOrder)
+	r.With(middlewares.AuthMiddleware).Get("/{id}/refunds", orderHandler.GetOrderRefunds)
+	r.With(middlewares.AuthMiddleware).Post("/{id}/refunds/{refundId}/fail", orderHandler.FailRefund)
+	r.With(middlewares.AuthMiddleware, idempotent).Post("/{id}/capture", orderHandler.CaptureOrder)
+	r.With(middlewares.AuthMiddleware, idempotent).Post("/{id}/void", orderHandler.VoidOrder)
+	r.With(middlewares.AuthMiddleware, idempotent).Post("/{id}/cancel", orderHandler.CancelOrder)
 }
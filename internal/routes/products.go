@@ -1,27 +1,71 @@
 package routes
 
 import (
+	"context"
+	"log"
+
+	"github.com/aakritigkmit/payment-gateway/internal/config"
 	"github.com/aakritigkmit/payment-gateway/internal/handlers"
 	middlewares "github.com/aakritigkmit/payment-gateway/internal/middleware"
 	"github.com/aakritigkmit/payment-gateway/internal/repository"
 	"github.com/aakritigkmit/payment-gateway/internal/services"
+	"github.com/aakritigkmit/payment-gateway/internal/utils"
 	"github.com/go-chi/chi/v5"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func SetupProductRoutes(r chi.Router, db *mongo.Database) {
 	productRepo := repository.NewProductRepo(db)
+	priceHistoryRepo := repository.NewProductPriceHistoryRepo(db)
+	syncProfileRepo := repository.NewSyncProfileRepo(db)
 	productTransactionRepo := repository.NewProductTransactionRepo(db)
 	productOrderRepo := repository.NewProductOrderRepo(db)
 
-	productService := services.NewProductService(productRepo, productTransactionRepo, productOrderRepo)
-	productHandler := handlers.NewProductHandler(productService)
+	webhookService := services.NewWebhookService(repository.NewWebhookRepo(db), config.GetConfig())
+
+	dtOneClient := utils.NewDTOneClient(config.GetConfig(), nil)
+
+	productService := services.NewProductService(productRepo, priceHistoryRepo, syncProfileRepo, productTransactionRepo, productOrderRepo, dtOneClient, webhookService)
+	backgroundJobRepo := repository.NewBackgroundJobRepo(db)
+	jobRegistry := services.NewJobRegistry(backgroundJobRepo)
+	callbackVerifier := services.NewCallbackVerifier(repository.NewCallbackRepo(db), config.GetConfig())
+	productHandler := handlers.NewProductHandler(productService, jobRegistry, callbackVerifier)
+
+	if err := productRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to ensure product indexes: %v", err)
+	}
+	if err := priceHistoryRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to ensure product price history indexes: %v", err)
+	}
+	if err := syncProfileRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to ensure sync profile indexes: %v", err)
+	}
+	if err := productTransactionRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to ensure product transaction indexes: %v", err)
+	}
+	if err := backgroundJobRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to ensure background job indexes: %v", err)
+	}
 
 	// Define routes
+	r.With(middlewares.AuthMiddleware).Get("/", productHandler.ListProducts)
+	r.With(middlewares.AuthMiddleware).Get("/{id}", productHandler.GetProduct)
+	r.With(middlewares.AuthMiddleware).Get("/{id}/price-history", productHandler.GetProductPriceHistory)
 	r.With(middlewares.AuthMiddleware).Post("/sync", productHandler.SyncProducts)
+	r.With(middlewares.AuthMiddleware).Post("/sync-profiles", productHandler.CreateSyncProfile)
+	r.With(middlewares.AuthMiddleware).Get("/sync-profiles", productHandler.ListSyncProfiles)
+	r.With(middlewares.AuthMiddleware).Get("/sync-profiles/{id}", productHandler.GetSyncProfile)
+	r.With(middlewares.AuthMiddleware).Patch("/sync-profiles/{id}", productHandler.UpdateSyncProfile)
+	r.With(middlewares.AuthMiddleware).Delete("/sync-profiles/{id}", productHandler.DeleteSyncProfile)
 	// r.With(middlewares.AuthMiddleware).Post("/report", productHandler.GenerateProductReport)
 	r.With(middlewares.AuthMiddleware).Post("/report", productHandler.GenerateProductReportByIDs)
 	r.With(middlewares.AuthMiddleware).Post("/transaction", productHandler.HandleProductTransaction)
 	r.With(middlewares.AuthMiddleware).Post("/transactions/bulk", productHandler.CreateBulkProductTransaction)
+	r.With(middlewares.AuthMiddleware).Post("/transactions/{externalId}/confirm", productHandler.ConfirmProductTransaction)
+	r.With(middlewares.AuthMiddleware).Post("/transactions/{externalId}/cancel", productHandler.CancelProductTransaction)
+	r.With(middlewares.AuthMiddleware).Get("/orders/{orderId}", productHandler.GetBulkOrderStatus)
+
+	// DT One callbacks authenticate with Basic auth, not user tokens.
+	r.Post("/callback", productHandler.HandleDTOneCallback)
 
 }
@@ -15,6 +15,8 @@ var routeRegistry = map[string]func(r chi.Router, db *mongo.Database){
 	"orders":   SetupOrderRoutes,
 	"products": SetupProductRoutes,
 	"dbs":      SetupDBSRoutes,
+	"webhooks": SetupWebhookRoutes,
+	"jobs":     SetupJobRoutes,
 }
 
 // SetupRoutes initializes all application routes with /api prefix
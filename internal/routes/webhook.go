@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"context"
+	"log"
+
+	"github.com/aakritigkmit/payment-gateway/internal/config"
+	"github.com/aakritigkmit/payment-gateway/internal/handlers"
+	middlewares "github.com/aakritigkmit/payment-gateway/internal/middleware"
+	"github.com/aakritigkmit/payment-gateway/internal/repository"
+	"github.com/aakritigkmit/payment-gateway/internal/services"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetupWebhookRoutes(r chi.Router, db *mongo.Database) {
+	webhookRepo := repository.NewWebhookRepo(db)
+	webhookService := services.NewWebhookService(webhookRepo, config.GetConfig())
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+
+	if err := webhookRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Failed to ensure webhook indexes: %v", err)
+	}
+
+	r.Use(middlewares.AuthMiddleware)
+
+	r.Post("/endpoints", webhookHandler.CreateEndpoint)
+	r.Get("/endpoints", webhookHandler.ListEndpoints)
+	r.Get("/endpoints/{id}", webhookHandler.GetEndpoint)
+	r.Patch("/endpoints/{id}", webhookHandler.UpdateEndpoint)
+	r.Delete("/endpoints/{id}", webhookHandler.DeleteEndpoint)
+	r.Get("/deliveries", webhookHandler.ListDeliveries)
+	r.Get("/deliveries/{id}", webhookHandler.GetDelivery)
+	r.Post("/deliveries/{id}/replay", webhookHandler.ReplayDelivery)
+}
@@ -0,0 +1,183 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed five-field cron expression: minute, hour, day of month, month
+// and day of week. Each field accepts *, single values, ranges (a-b), lists (a,b) and
+// steps (*/n, a-b/n). Months and weekdays also accept three-letter names, and the
+// descriptors @yearly, @monthly, @weekly, @daily and @hourly are supported.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// Per cron convention, when both day fields are restricted a day matches if
+	// either of them does.
+	domStar, dowStar bool
+}
+
+type cronField struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = cronField{min: 0, max: 59}
+	hourField   = cronField{min: 0, max: 23}
+	domField    = cronField{min: 1, max: 31}
+	monthField  = cronField{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = cronField{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a cron expression.
+func ParseCron(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if expanded, ok := cronDescriptors[strings.ToLower(spec)]; ok {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	var (
+		s   Schedule
+		err error
+	)
+	if s.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, fmt.Errorf("cron %q: minute: %w", spec, err)
+	}
+	if s.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, fmt.Errorf("cron %q: hour: %w", spec, err)
+	}
+	if s.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, fmt.Errorf("cron %q: day of month: %w", spec, err)
+	}
+	if s.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, fmt.Errorf("cron %q: month: %w", spec, err)
+	}
+	if s.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, fmt.Errorf("cron %q: day of week: %w", spec, err)
+	}
+	// 7 is accepted as Sunday, as in most crons.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = strings.HasPrefix(fields[2], "*")
+	s.dowStar = strings.HasPrefix(fields[4], "*")
+	return &s, nil
+}
+
+func (f cronField) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangeExpr, stepExpr, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepExpr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepExpr)
+			}
+			step = n
+		}
+
+		var lo, hi int
+		switch {
+		case rangeExpr == "*":
+			lo, hi = f.min, f.max
+		case strings.Contains(rangeExpr, "-"):
+			from, to, _ := strings.Cut(rangeExpr, "-")
+			var err error
+			if lo, err = f.value(from); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(to); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q", rangeExpr)
+			}
+		default:
+			var err error
+			if lo, err = f.value(rangeExpr); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if hasStep {
+				hi = f.max
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (f cronField) value(expr string) (int, error) {
+	if n, ok := f.names[strings.ToLower(expr)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(expr)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("value %q out of range %d-%d", expr, f.min, f.max)
+	}
+	return n, nil
+}
+
+// Next returns the first time after t that matches the schedule, in t's location,
+// or the zero time if none exists within five years (e.g. "0 0 30 2 *").
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
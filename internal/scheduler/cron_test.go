@@ -0,0 +1,99 @@
+package scheduler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/scheduler"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{spec: "* * * * *"},
+		{spec: "*/15 0-6,22,23 1-31/2 * mon-fri"},
+		{spec: "0 9 1 JAN-mar/2 *"},
+		{spec: "0 0 * * 7"},
+		{spec: "@daily"},
+		{spec: "  @HOURLY  "},
+		{spec: "", wantErr: true},
+		{spec: "* * * *", wantErr: true},
+		{spec: "* * * * * *", wantErr: true},
+		{spec: "60 * * * *", wantErr: true},
+		{spec: "* 24 * * *", wantErr: true},
+		{spec: "* * 0 * *", wantErr: true},
+		{spec: "* * 32 * *", wantErr: true},
+		{spec: "* * * 13 *", wantErr: true},
+		{spec: "* * * * 8", wantErr: true},
+		{spec: "*/0 * * * *", wantErr: true},
+		{spec: "*/x * * * *", wantErr: true},
+		{spec: "30-10 * * * *", wantErr: true},
+		{spec: "* * * foo *", wantErr: true},
+		{spec: "a * * * *", wantErr: true},
+		{spec: "@every 5m", wantErr: true},
+	}
+	for _, tt := range tests {
+		_, err := scheduler.ParseCron(tt.spec)
+		if tt.wantErr && err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want an error", tt.spec)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("ParseCron(%q): %v", tt.spec, err)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	at := func(year int, month time.Month, day, hour, min, sec int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"every minute", "* * * * *", at(2025, 3, 10, 12, 34, 56), at(2025, 3, 10, 12, 35, 0)},
+		{"strictly after", "30 12 * * *", at(2025, 3, 10, 12, 30, 0), at(2025, 3, 11, 12, 30, 0)},
+		{"list", "0,30 * * * *", at(2025, 3, 10, 12, 10, 0), at(2025, 3, 10, 12, 30, 0)},
+		{"minute step", "*/15 * * * *", at(2025, 3, 10, 12, 34, 0), at(2025, 3, 10, 12, 45, 0)},
+		{"minute step wraps the hour", "*/15 * * * *", at(2025, 3, 10, 12, 45, 0), at(2025, 3, 10, 13, 0, 0)},
+		{"hour step wraps the day", "0 */6 * * *", at(2025, 3, 10, 19, 0, 0), at(2025, 3, 11, 0, 0, 0)},
+		{"range step", "5-20/5 * * * *", at(2025, 3, 10, 12, 20, 0), at(2025, 3, 10, 13, 5, 0)},
+		{"value step runs to the end", "50/5 * * * *", at(2025, 3, 10, 12, 55, 0), at(2025, 3, 10, 13, 50, 0)},
+		{"day step", "0 0 */10 * *", at(2025, 1, 21, 0, 0, 0), at(2025, 1, 31, 0, 0, 0)},
+		{"year end", "30 2 * * *", at(2025, 12, 31, 23, 0, 0), at(2026, 1, 1, 2, 30, 0)},
+		{"first of month", "0 0 1 * *", at(2025, 12, 15, 0, 0, 0), at(2026, 1, 1, 0, 0, 0)},
+		{"31st skips february", "0 0 31 * *", at(2025, 1, 31, 0, 0, 0), at(2025, 3, 31, 0, 0, 0)},
+		{"31st skips april", "0 0 31 * *", at(2025, 3, 31, 0, 0, 0), at(2025, 5, 31, 0, 0, 0)},
+		{"30th skips february", "0 0 30 * *", at(2025, 1, 30, 0, 0, 0), at(2025, 3, 30, 0, 0, 0)},
+		{"leap day", "0 0 29 2 *", at(2025, 1, 1, 0, 0, 0), at(2028, 2, 29, 0, 0, 0)},
+		{"impossible date", "0 0 30 2 *", at(2025, 1, 1, 0, 0, 0), time.Time{}},
+		{"month names", "0 9 1 jan-mar/2 *", at(2025, 1, 2, 0, 0, 0), at(2025, 3, 1, 9, 0, 0)},
+		{"weekday range", "0 9 * * mon-fri", at(2025, 1, 3, 10, 0, 0), at(2025, 1, 6, 9, 0, 0)},
+		{"sunday as 7", "0 0 * * 7", at(2025, 1, 1, 0, 0, 0), at(2025, 1, 5, 0, 0, 0)},
+		{"weekly", "@weekly", at(2025, 1, 1, 0, 0, 0), at(2025, 1, 5, 0, 0, 0)},
+		{"monthly", "@monthly", at(2025, 2, 1, 0, 0, 0), at(2025, 3, 1, 0, 0, 0)},
+		{"yearly", "@yearly", at(2025, 1, 1, 0, 0, 0), at(2026, 1, 1, 0, 0, 0)},
+
+		// When both day fields are restricted a day matches if either does.
+		{"day or weekday: weekday first", "0 0 13 * fri", at(2025, 1, 1, 0, 0, 0), at(2025, 1, 3, 0, 0, 0)},
+		{"day or weekday: day first", "0 0 13 * fri", at(2025, 1, 10, 0, 0, 0), at(2025, 1, 13, 0, 0, 0)},
+		// A day field starting with * does not count as restricted, so both must match.
+		{"day step and weekday", "0 0 */10 * mon", at(2025, 1, 1, 0, 0, 0), at(2025, 3, 31, 0, 0, 0)},
+		{"any day and weekday", "0 0 * * mon", at(2025, 1, 1, 0, 0, 0), at(2025, 1, 6, 0, 0, 0)},
+	}
+	for _, tt := range tests {
+		s, err := scheduler.ParseCron(tt.spec)
+		if err != nil {
+			t.Errorf("%s: ParseCron(%q): %v", tt.name, tt.spec, err)
+			continue
+		}
+		if got := s.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%s: %q.Next(%s) = %s, want %s", tt.name, tt.spec, tt.from.Format(time.RFC3339), got.Format(time.RFC3339), tt.want.Format(time.RFC3339))
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Package scheduler runs recurring background jobs on cron schedules. Every replica
+// registers the same jobs; a Redis lock per job makes sure only one of them runs each
+// occurrence, and every run is recorded in Mongo.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"github.com/aakritigkmit/payment-gateway/internal/repository"
+	"github.com/aakritigkmit/payment-gateway/internal/utils"
+)
+
+const (
+	lockKeyPrefix  = "scheduler:lock:"
+	claimKeyPrefix = "scheduler:claim:"
+	// claimTTL outlives any clock skew between replicas.
+	claimTTL = time.Hour
+)
+
+// JobFunc runs one occurrence of a job and returns a short summary for the run history.
+type JobFunc func(ctx context.Context) (string, error)
+
+type job struct {
+	name     string
+	spec     string
+	schedule *Schedule
+	run      JobFunc
+}
+
+type Scheduler struct {
+	repo     *repository.JobRepo
+	lockTTL  time.Duration
+	instance string
+	jobs     []*job
+}
+
+// New returns a scheduler that records runs in repo. lockTTL bounds how long a crashed
+// replica can keep a job locked; running jobs renew their lock well before it expires.
+func New(repo *repository.JobRepo, lockTTL time.Duration) *Scheduler {
+	host, _ := os.Hostname()
+	return &Scheduler{
+		repo:     repo,
+		lockTTL:  lockTTL,
+		instance: fmt.Sprintf("%s:%d", host, os.Getpid()),
+	}
+}
+
+// Register adds a job. An empty spec leaves the job disabled.
+func (s *Scheduler) Register(name, spec string, fn JobFunc) error {
+	if spec == "" {
+		log.Printf("[Scheduler] Job %s is disabled", name)
+		return nil
+	}
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+	s.jobs = append(s.jobs, &job{name: name, spec: spec, schedule: schedule, run: fn})
+	return nil
+}
+
+// Start runs every registered job on its schedule until ctx is cancelled, then waits
+// for running jobs to return.
+func (s *Scheduler) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, j := range s.jobs {
+		wg.Add(1)
+		go func(j *job) {
+			defer wg.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+	wg.Wait()
+	log.Println("[Scheduler] Stopped.")
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	for {
+		next := j.schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("[Scheduler] Job %s (%s) never fires again", j.name, j.spec)
+			return
+		}
+		if err := s.repo.RegisterJob(ctx, j.name, j.spec, next); err != nil {
+			log.Printf("[Scheduler] %v", err)
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.runOnce(ctx, j, next)
+		}
+	}
+}
+
+// runOnce runs the occurrence of j due at at. Each occurrence is claimed once across
+// replicas, so a replica whose clock runs late cannot run it again after a short job
+// has already finished; the lock held while running keeps a slow run from overlapping
+// the next occurrence. Losing the claim is normal and not logged.
+func (s *Scheduler) runOnce(ctx context.Context, j *job, at time.Time) {
+	claimKey := fmt.Sprintf("%s%s:%d", claimKeyPrefix, j.name, at.Unix())
+	claimed, err := utils.SetRedisKeyNX(ctx, claimKey, s.instance, claimTTL)
+	if err != nil {
+		log.Printf("[Scheduler] Claim for %s failed: %v", j.name, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	key := lockKeyPrefix + j.name
+	token, ok, err := utils.AcquireRedisLock(ctx, key, s.lockTTL)
+	if err != nil {
+		log.Printf("[Scheduler] Lock for %s failed: %v", j.name, err)
+		return
+	}
+	if !ok {
+		log.Printf("[Scheduler] Skipping %s: previous run still in progress", j.name)
+		return
+	}
+	defer func() {
+		if err := utils.ReleaseRedisLock(context.Background(), key, token); err != nil {
+			log.Printf("[Scheduler] Releasing lock for %s failed: %v", j.name, err)
+		}
+	}()
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go s.renewLock(jobCtx, cancel, j.name, key, token)
+
+	run, err := s.repo.StartRun(ctx, model.JobRun{
+		Job:       j.name,
+		Status:    model.JobRunRunning,
+		Instance:  s.instance,
+		StartedAt: time.Now(),
+	})
+	if err != nil {
+		log.Printf("[Scheduler] %v", err)
+		return
+	}
+	log.Printf("[Scheduler] Running %s", j.name)
+
+	summary, runErr := j.run(jobCtx)
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.DurationMs = finished.Sub(run.StartedAt).Milliseconds()
+	run.Summary = summary
+	run.Status = model.JobRunSucceeded
+	if runErr != nil {
+		run.Status = model.JobRunFailed
+		run.Error = runErr.Error()
+	}
+	log.Printf("[Scheduler] %s %s in %v", j.name, run.Status, finished.Sub(run.StartedAt))
+
+	// The run is recorded even when ctx was cancelled mid-run.
+	if err := s.repo.FinishRun(context.Background(), run); err != nil {
+		log.Printf("[Scheduler] %v", err)
+	}
+}
+
+// renewLock keeps the job's lock alive while it runs, and cancels the job if the lock
+// is lost so two replicas never run it at once.
+func (s *Scheduler) renewLock(ctx context.Context, cancel context.CancelFunc, name, key, token string) {
+	ticker := time.NewTicker(s.lockTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ok, err := utils.ExtendRedisLock(ctx, key, token, s.lockTTL)
+			if err != nil {
+				log.Printf("[Scheduler] Renewing lock for %s failed: %v", name, err)
+				continue
+			}
+			if !ok {
+				log.Printf("[Scheduler] Lost lock for %s; cancelling run", name)
+				cancel()
+				return
+			}
+		}
+	}
+}
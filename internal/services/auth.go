@@ -67,7 +67,7 @@ func (s *AuthService) Login(email, password string) (string, error) {
 	}
 
 	// Generate JWT token
-	tokenString, err := utils.GenerateJWT(user.ID.Hex(), user.Email)
+	tokenString, err := utils.GenerateJWT(user.ID.Hex(), user.Email, user.Role)
 	if err != nil {
 		fmt.Println("Error generating JWT:", err) // Print error
 		return "", errors.New("failed to generate token")
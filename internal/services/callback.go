@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/config"
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"github.com/aakritigkmit/payment-gateway/internal/repository"
+	"github.com/aakritigkmit/payment-gateway/internal/utils"
+)
+
+// ErrCallbackRejected is returned when an inbound callback fails verification.
+var ErrCallbackRejected = errors.New("callback rejected")
+
+const pineLabsNonceKeyPrefix = "pinelabs:callback_nonce:"
+
+type CallbackVerifier struct {
+	repo      *repository.CallbackRepo
+	secret    string
+	tolerance time.Duration
+
+	dtOneUsername string
+	dtOnePassword string
+}
+
+func NewCallbackVerifier(repo *repository.CallbackRepo, cfg *config.Config) *CallbackVerifier {
+	return &CallbackVerifier{
+		repo:          repo,
+		secret:        cfg.PinelabsWebhookSecret,
+		tolerance:     cfg.PinelabsCallbackTolerance,
+		dtOneUsername: cfg.DtOneCallbackUsername,
+		dtOnePassword: cfg.DtOneCallbackPassword,
+	}
+}
+
+// VerifyPineLabsCallback checks the HMAC signature over the posted form fields,
+// rejects timestamps outside the tolerance window and nonces already seen.
+// Every rejection is logged to the callback_rejections collection.
+func (v *CallbackVerifier) VerifyPineLabsCallback(ctx context.Context, form url.Values, remoteAddr string) error {
+	reason := v.checkPineLabsCallback(ctx, form)
+	if reason == "" {
+		return nil
+	}
+
+	fields := make(map[string]string, len(form))
+	for key := range form {
+		fields[key] = form.Get(key)
+	}
+
+	rejection := model.CallbackRejection{
+		Source:     "pinelabs",
+		OrderID:    form.Get("order_id"),
+		Reason:     reason,
+		RemoteAddr: remoteAddr,
+		Fields:     fields,
+		CreatedAt:  time.Now(),
+	}
+	if err := v.repo.SaveRejection(ctx, rejection); err != nil {
+		log.Printf("[Callback] Failed to log rejected callback: %v", err)
+	}
+
+	log.Printf("[Callback] Rejected Pine Labs callback for order %s from %s: %s", rejection.OrderID, remoteAddr, reason)
+	return fmt.Errorf("%w: %s", ErrCallbackRejected, reason)
+}
+
+// VerifyDTOneCallback checks the Basic auth credentials DT One sends with transaction
+// callbacks. Rejections are logged like Pine Labs ones.
+func (v *CallbackVerifier) VerifyDTOneCallback(ctx context.Context, username, password string, ok bool, remoteAddr string) error {
+	var reason string
+	switch {
+	case v.dtOneUsername == "":
+		reason = "callback credentials not configured"
+	case !ok:
+		reason = "missing credentials"
+	case subtle.ConstantTimeCompare([]byte(username), []byte(v.dtOneUsername)) != 1 ||
+		subtle.ConstantTimeCompare([]byte(password), []byte(v.dtOnePassword)) != 1:
+		reason = "invalid credentials"
+	default:
+		return nil
+	}
+
+	rejection := model.CallbackRejection{
+		Source:     "dtone",
+		Reason:     reason,
+		RemoteAddr: remoteAddr,
+		Fields:     map[string]string{"username": username},
+		CreatedAt:  time.Now(),
+	}
+	if err := v.repo.SaveRejection(ctx, rejection); err != nil {
+		log.Printf("[Callback] Failed to log rejected callback: %v", err)
+	}
+
+	log.Printf("[Callback] Rejected DT One callback from %s: %s", remoteAddr, reason)
+	return fmt.Errorf("%w: %s", ErrCallbackRejected, reason)
+}
+
+// checkPineLabsCallback returns the rejection reason, or "" if the callback is valid.
+func (v *CallbackVerifier) checkPineLabsCallback(ctx context.Context, form url.Values) string {
+	if v.secret == "" {
+		return "callback secret not configured"
+	}
+
+	signature := form.Get("signature")
+	if signature == "" {
+		return "missing signature"
+	}
+	if !utils.VerifyHMACSHA256(v.secret, canonicalCallbackPayload(form), signature) {
+		return "invalid signature"
+	}
+
+	ts, err := strconv.ParseInt(form.Get("timestamp"), 10, 64)
+	if err != nil {
+		return "missing or invalid timestamp"
+	}
+	age := time.Since(time.Unix(ts, 0))
+	if age > v.tolerance || age < -v.tolerance {
+		return "timestamp outside tolerance window"
+	}
+
+	nonce := form.Get("nonce")
+	if nonce == "" {
+		return "missing nonce"
+	}
+	// Nonces only need to outlive the window in which their timestamp is accepted.
+	fresh, err := utils.SetRedisKeyNX(ctx, pineLabsNonceKeyPrefix+nonce, ts, 2*v.tolerance)
+	if err != nil {
+		return "nonce check failed"
+	}
+	if !fresh {
+		return "replayed nonce"
+	}
+
+	return ""
+}
+
+// canonicalCallbackPayload builds the signed string: every form field except
+// the signature, sorted by key and joined as key=value pairs with '&'.
+func canonicalCallbackPayload(form url.Values) []byte {
+	keys := make([]string, 0, len(form))
+	for key := range form {
+		if key == "signature" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+"="+form.Get(key))
+	}
+	return []byte(strings.Join(parts, "&"))
+}
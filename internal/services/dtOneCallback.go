@@ -0,0 +1,32 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+)
+
+// HandleTransactionCallback applies a DT One transaction status callback. DT One may
+// deliver callbacks late, out of order or more than once: a callback for a state the
+// transaction has already moved past is ignored, and a pin is added to its bulk order
+// only once.
+func (s *ProductService) HandleTransactionCallback(ctx context.Context, tx model.ProductTransaction) error {
+	saved, applied, err := s.saveTransactionUpdate(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		log.Printf("[DTOneCallback] Ignoring stale %s callback for %s", tx.Status.Message, tx.ExternalID)
+		return nil
+	}
+	log.Printf("[DTOneCallback] Transaction %s is %s", tx.ExternalID, tx.Status.Message)
+
+	if saved.OrderID == "" || saved.Pin.Code == "" {
+		return nil
+	}
+	pin := model.ProductPinItem{ExternalID: saved.ExternalID, ProductID: saved.Product.UniqueId}
+	pin.Pin.Code = saved.Pin.Code
+	pin.Pin.Serial = saved.Pin.Serial
+	return s.productOrderRepo.AddProductPins(ctx, saved.OrderID, []model.ProductPinItem{pin})
+}
@@ -0,0 +1,24 @@
+package services
+
+import "errors"
+
+var (
+	// ErrInvalidOrderState is returned when an operation is not allowed in the order's current status.
+	ErrInvalidOrderState = errors.New("operation not allowed in current order status")
+	// ErrInvalidAmount is returned when a requested amount is out of range for the order.
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrInvalidWebhookRequest is returned for malformed webhook endpoint or delivery input.
+	ErrInvalidWebhookRequest = errors.New("invalid webhook request")
+	// ErrInvalidSyncProfile is returned for malformed sync profile input.
+	ErrInvalidSyncProfile = errors.New("invalid sync profile")
+	// ErrInvalidTransactionRequest is returned when a purchase does not fit the product, e.g.
+	// an amount for a fixed-value product.
+	ErrInvalidTransactionRequest = errors.New("invalid transaction request")
+	// ErrInvalidTransactionState is returned when confirming or cancelling a DT One transaction
+	// that is no longer awaiting confirmation.
+	ErrInvalidTransactionState = errors.New("operation not allowed in current transaction status")
+	// ErrSupportOnly is returned when an operation is reserved for support users.
+	ErrSupportOnly = errors.New("operation requires the support role")
+	// ErrJobNotCancellable is returned when cancelling a background job that has already finished.
+	ErrJobNotCancellable = errors.New("job has already finished")
+)
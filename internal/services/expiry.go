@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/config"
+	"github.com/aakritigkmit/payment-gateway/internal/repository"
+)
+
+const (
+	// expiryRetryBase is the wait before retrying an order whose expiry failed; it
+	// doubles with every further failure up to expiryRetryMax.
+	expiryRetryBase = time.Minute
+	expiryRetryMax  = 6 * time.Hour
+)
+
+// OrderExpirer expires orders that stay unpaid for longer than the configured TTL.
+type OrderExpirer struct {
+	orderService *OrderService
+	orderRepo    *repository.OrderRepo
+	ttl          time.Duration
+	batchSize    int
+}
+
+func NewOrderExpirer(orderService *OrderService, orderRepo *repository.OrderRepo, cfg *config.Config) *OrderExpirer {
+	return &OrderExpirer{
+		orderService: orderService,
+		orderRepo:    orderRepo,
+		ttl:          cfg.OrderTTL,
+		batchSize:    cfg.OrderExpiryBatchSize,
+	}
+}
+
+// Start runs the expirer every interval until ctx is cancelled.
+func (e *OrderExpirer) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[Expiry] Stopped.")
+			return
+		case <-ticker.C:
+			if _, err := e.Run(ctx); err != nil {
+				log.Printf("[Expiry] Run failed: %v", err)
+			}
+		}
+	}
+}
+
+// Run expires one batch of unpaid orders older than the TTL and returns how many were expired.
+func (e *OrderExpirer) Run(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-e.ttl)
+	orders, err := e.orderRepo.FindUnpaidOrders(ctx, cutoff, int64(e.batchSize))
+	if err != nil {
+		return 0, err
+	}
+
+	expired, failed := 0, 0
+	for _, order := range orders {
+		if ctx.Err() != nil {
+			return expired, ctx.Err()
+		}
+
+		ok, err := e.orderService.ExpireOrder(ctx, order.TransactionReferenceId)
+		if err != nil {
+			failed++
+			// Back the order off so orders that keep failing do not fill every batch.
+			retryAt := time.Now().Add(expiryRetryDelay(order.ExpiryAttempts + 1))
+			log.Printf("[Expiry] Order %s: %v (retrying after %s)", order.TransactionReferenceId, err, retryAt.Format(time.RFC3339))
+			if err := e.orderRepo.DeferExpiry(ctx, order.TransactionReferenceId, retryAt); err != nil {
+				log.Printf("[Expiry] Order %s: %v", order.TransactionReferenceId, err)
+			}
+			continue
+		}
+		if ok {
+			expired++
+		}
+	}
+
+	log.Printf("[Expiry] Checked: %d, Expired: %d, Failed: %d (TTL %v)", len(orders), expired, failed, e.ttl)
+	return expired, nil
+}
+
+// expiryRetryDelay returns the wait after the given number of failed expiry attempts.
+func expiryRetryDelay(attempts int) time.Duration {
+	delay := expiryRetryBase
+	for i := 1; i < attempts && delay < expiryRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > expiryRetryMax {
+		delay = expiryRetryMax
+	}
+	return delay
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/dto"
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"github.com/aakritigkmit/payment-gateway/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JobService exposes the scheduler's job list and run history, and the background
+// jobs started from the API.
+type JobService struct {
+	repo           *repository.JobRepo
+	backgroundRepo *repository.BackgroundJobRepo
+}
+
+func NewJobService(repo *repository.JobRepo, backgroundRepo *repository.BackgroundJobRepo) *JobService {
+	return &JobService{repo: repo, backgroundRepo: backgroundRepo}
+}
+
+func (s *JobService) GetJob(ctx context.Context, id string) (model.BackgroundJob, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.BackgroundJob{}, fmt.Errorf("%w: invalid id %q", repository.ErrBackgroundJobNotFound, id)
+	}
+	if _, err := s.failIfStale(ctx, objectID); err != nil {
+		return model.BackgroundJob{}, err
+	}
+	return s.backgroundRepo.Get(ctx, objectID)
+}
+
+// CancelJob asks a running background job to stop. The job moves to cancelled once the
+// replica running it notices, within a few seconds. A job whose replica has stopped
+// heartbeating is failed instead and returned as is.
+func (s *JobService) CancelJob(ctx context.Context, id string) (model.BackgroundJob, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.BackgroundJob{}, fmt.Errorf("%w: invalid id %q", repository.ErrBackgroundJobNotFound, id)
+	}
+	failed, err := s.failIfStale(ctx, objectID)
+	if err != nil {
+		return model.BackgroundJob{}, err
+	}
+	if failed {
+		return s.backgroundRepo.Get(ctx, objectID)
+	}
+	job, err := s.backgroundRepo.RequestCancel(ctx, objectID)
+	if err != nil {
+		return model.BackgroundJob{}, err
+	}
+	if job.Status.IsFinal() {
+		return model.BackgroundJob{}, fmt.Errorf("%w: status is %s", ErrJobNotCancellable, job.Status)
+	}
+	return job, nil
+}
+
+// failIfStale fails the job if it is running but its replica stopped heartbeating, so
+// it is never reported as running forever. It reports whether the job was failed.
+func (s *JobService) failIfStale(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	n, err := s.backgroundRepo.FailStale(ctx, time.Now().Add(-jobStaleAfter), id)
+	return n > 0, err
+}
+
+func (s *JobService) ListJobs(ctx context.Context) ([]model.ScheduledJob, error) {
+	return s.repo.ListJobs(ctx)
+}
+
+func (s *JobService) ListRuns(ctx context.Context, job, status, cursor string, limit int64) (dto.JobRunListResponse, error) {
+	runs, err := s.repo.ListRuns(ctx, job, status, cursor, limit)
+	if err != nil {
+		return dto.JobRunListResponse{}, err
+	}
+
+	resp := dto.JobRunListResponse{Runs: runs}
+	if int64(len(runs)) == limit {
+		resp.NextCursor = runs[len(runs)-1].ID.Hex()
+	}
+	return resp, nil
+}
+
+func (s *JobService) GetRun(ctx context.Context, id string) (model.JobRun, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.JobRun{}, fmt.Errorf("%w: invalid id %q", repository.ErrJobRunNotFound, id)
+	}
+	return s.repo.GetRun(ctx, objectID)
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"github.com/aakritigkmit/payment-gateway/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// jobFlushInterval is how often a running job's progress is saved and checked
+	// for cancellation.
+	jobFlushInterval = 2 * time.Second
+	// jobStaleAfter is how long a running job may go without a flush before it is
+	// presumed lost with its replica.
+	jobStaleAfter = time.Minute
+	// maxJobErrors caps the errors stored on a job record; counters keep counting.
+	maxJobErrors = 500
+)
+
+// JobRegistry runs background jobs detached from the request that started them and
+// records their progress.
+type JobRegistry struct {
+	repo     *repository.BackgroundJobRepo
+	instance string
+}
+
+func NewJobRegistry(repo *repository.BackgroundJobRepo) *JobRegistry {
+	host, _ := os.Hostname()
+	return &JobRegistry{repo: repo, instance: fmt.Sprintf("%s:%d", host, os.Getpid())}
+}
+
+// Launch records a job and runs fn in the background. The returned record is in the
+// running state; fn's ctx is cancelled when the job is cancelled through the API.
+// params is stored for reference and should hold flat values only.
+//
+// Every flush also bumps the record's updated_at, which serves as the job's
+// heartbeat: a job whose replica crashed or restarted stops heartbeating and is
+// failed by FailStaleJobs once jobStaleAfter has passed.
+func (r *JobRegistry) Launch(jobType model.BackgroundJobType, params map[string]interface{}, fn func(ctx context.Context, tracker *JobTracker) error) (model.BackgroundJob, error) {
+	now := time.Now()
+	job, err := r.repo.Create(context.Background(), model.BackgroundJob{
+		Type:        jobType,
+		Status:      model.BackgroundJobRunning,
+		Params:      params,
+		SaveErrors:  []model.ProductSaveError{},
+		FetchErrors: []model.FetchPageError{},
+		Instance:    r.instance,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	if err != nil {
+		return model.BackgroundJob{}, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tracker := &JobTracker{
+		id:          job.ID,
+		repo:        r.repo,
+		saveErrors:  []model.ProductSaveError{},
+		fetchErrors: []model.FetchPageError{},
+	}
+
+	go func() {
+		defer cancel()
+
+		flushDone := make(chan struct{})
+		go func() {
+			defer close(flushDone)
+			tracker.flushLoop(ctx, cancel)
+		}()
+
+		runErr := fn(ctx, tracker)
+		// Only a cancellation that reached fn counts; one requested after fn
+		// returned is picked up by the final flush but changes nothing.
+		cancelled := tracker.isCancelled()
+		cancel()
+		<-flushDone
+		tracker.flush(context.Background())
+
+		status, errMsg := model.BackgroundJobSucceeded, ""
+		switch {
+		case cancelled:
+			status = model.BackgroundJobCancelled
+		case runErr != nil:
+			status, errMsg = model.BackgroundJobFailed, runErr.Error()
+		}
+		if err := r.repo.Finish(context.Background(), job.ID, status, errMsg); err != nil {
+			log.Printf("[Jobs] %v", err)
+		}
+		log.Printf("[Jobs] %s job %s %s", jobType, job.ID.Hex(), status)
+	}()
+
+	return job, nil
+}
+
+// FailStaleJobs fails running jobs that have not heartbeated within jobStaleAfter.
+func (r *JobRegistry) FailStaleJobs(ctx context.Context) (int64, error) {
+	return r.repo.FailStale(ctx, time.Now().Add(-jobStaleAfter))
+}
+
+// JobTracker collects a running job's progress. A nil tracker discards everything, so
+// the same code can run untracked, e.g. from the scheduler.
+type JobTracker struct {
+	id   primitive.ObjectID
+	repo *repository.BackgroundJobRepo
+
+	mu          sync.Mutex
+	progress    model.JobProgress
+	saveErrors  []model.ProductSaveError
+	fetchErrors []model.FetchPageError
+	cancelled   bool
+}
+
+// AddTotal grows the amount of work expected.
+func (t *JobTracker) AddTotal(n int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.progress.Total += n
+	t.mu.Unlock()
+}
+
+// Succeeded counts n units of work done.
+func (t *JobTracker) Succeeded(n int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.progress.Processed += n
+	t.progress.Succeeded += n
+	t.mu.Unlock()
+}
+
+// Failed counts n units of work that could not be done.
+func (t *JobTracker) Failed(n int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.progress.Processed += n
+	t.progress.Failed += n
+	t.mu.Unlock()
+}
+
+func (t *JobTracker) RecordSaveError(e model.ProductSaveError) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	if len(t.saveErrors) < maxJobErrors {
+		t.saveErrors = append(t.saveErrors, e)
+	}
+	t.mu.Unlock()
+}
+
+func (t *JobTracker) RecordFetchError(e model.FetchPageError) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	if len(t.fetchErrors) < maxJobErrors {
+		t.fetchErrors = append(t.fetchErrors, e)
+	}
+	t.mu.Unlock()
+}
+
+func (t *JobTracker) isCancelled() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cancelled
+}
+
+func (t *JobTracker) flushLoop(ctx context.Context, cancel context.CancelFunc) {
+	ticker := time.NewTicker(jobFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if t.flush(ctx) {
+				log.Printf("[Jobs] Job %s cancelled", t.id.Hex())
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// flush saves progress and reports whether the job has been cancelled.
+func (t *JobTracker) flush(ctx context.Context) bool {
+	t.mu.Lock()
+	progress := t.progress
+	saveErrors := append(make([]model.ProductSaveError, 0, len(t.saveErrors)), t.saveErrors...)
+	fetchErrors := append(make([]model.FetchPageError, 0, len(t.fetchErrors)), t.fetchErrors...)
+	t.mu.Unlock()
+
+	cancelRequested, err := t.repo.SaveProgress(ctx, t.id, progress, saveErrors, fetchErrors)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			log.Printf("[Jobs] %v", err)
+		}
+		return false
+	}
+	if cancelRequested {
+		t.mu.Lock()
+		t.cancelled = true
+		t.mu.Unlock()
+	}
+	return cancelRequested
+}
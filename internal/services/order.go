@@ -2,15 +2,22 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/aakritigkmit/payment-gateway/internal/dto"
 	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 
-	"github.com/aakritigkmit/payment-gateway/internal/helpers"
 	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"github.com/aakritigkmit/payment-gateway/internal/money"
+	"github.com/aakritigkmit/payment-gateway/internal/providers"
 	"github.com/aakritigkmit/payment-gateway/internal/repository"
 	"github.com/aakritigkmit/payment-gateway/internal/utils"
 )
@@ -18,10 +25,25 @@ import (
 type OrderService struct {
 	repo            *repository.OrderRepo
 	transactionRepo *repository.TransactionRepo
+	refundRepo      *repository.RefundRepo
+	providers       *providers.Registry
+	webhooks        *WebhookService
 }
 
-func NewOrderService(repo *repository.OrderRepo, transactionRepo *repository.TransactionRepo) *OrderService {
-	return &OrderService{repo, transactionRepo}
+func NewOrderService(repo *repository.OrderRepo, transactionRepo *repository.TransactionRepo, refundRepo *repository.RefundRepo, providerRegistry *providers.Registry, webhooks *WebhookService) *OrderService {
+	return &OrderService{repo, transactionRepo, refundRepo, providerRegistry, webhooks}
+}
+
+// providerForOrder returns the provider that owns an existing order, as recorded on its transaction.
+func (s *OrderService) providerForOrder(ctx context.Context, orderID string) (providers.PaymentProvider, error) {
+	tx, err := s.transactionRepo.GetTransactionByPineOrderID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return s.providers.Get("")
+		}
+		return nil, fmt.Errorf("failed to load transaction: %w", err)
+	}
+	return s.providers.Get(tx.Provider)
 }
 
 func (s *OrderService) FetchAndUpdateTransactionDetails(ctx context.Context, orderID string) {
@@ -29,51 +51,121 @@ func (s *OrderService) FetchAndUpdateTransactionDetails(ctx context.Context, ord
 		// Use background context to detach from request lifecycle
 		bgCtx := context.Background()
 
-		tokenResp, err := utils.FetchAccessToken(ctx)
-		if err != nil {
-			return
+		if _, _, err := s.SyncOrderWithProvider(bgCtx, orderID, model.ActorPineLabsCallback); err != nil {
+			log.Printf("[Order] Sync of order %s after callback failed: %v", orderID, err)
 		}
+	}()
+}
 
-		data, err := utils.GetOrderDetails(bgCtx, tokenResp.AccessToken, orderID)
+// SyncOrderWithProvider re-fetches the order from its provider, rewrites the stored
+// transaction and moves the order to the matching state. It returns the order status
+// before and after the sync.
+func (s *OrderService) SyncOrderWithProvider(ctx context.Context, orderID, actor string) (model.OrderStatus, model.OrderStatus, error) {
+	order, err := s.repo.GetOrderByTransactionReferenceId(ctx, orderID)
+	if err != nil {
+		return "", "", err
+	}
+	before := model.NormalizeOrderStatus(string(order.Status))
 
-		jsonBytes, _ := json.MarshalIndent(data, "", "  ")
-		fmt.Println(string(jsonBytes))
-		fmt.Println("Updating transaction with data:", data)
-		if err != nil {
-			fmt.Println("err:", err)
-			return
-		}
+	provider, err := s.providerForOrder(ctx, orderID)
+	if err != nil {
+		return before, before, err
+	}
+
+	data, err := provider.GetOrder(ctx, orderID)
+	if err != nil {
+		return before, before, err
+	}
 
-		// Parse and update the existing transaction and order in DB
-		transactionModel := helpers.MapPineOrderToTransactionModel(data)
-		fmt.Println("Updating transaction with transactionModel:", transactionModel)
+	// Parse and update the existing transaction and order in DB
+	if err := s.saveProviderOrder(ctx, orderID, provider, data); err != nil {
+		return before, before, err
+	}
 
-		// Save or update transaction in your DB
-		err = s.transactionRepo.UpdateTransactionByOrderID(bgCtx, orderID, transactionModel)
-		if err != nil {
-			fmt.Println("err:", err)
+	updated, err := s.applyProviderStatus(ctx, orderID, data, actor)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidStatusTransition) {
+			return before, before, nil
 		}
-	}()
+		return before, before, err
+	}
+
+	return before, model.NormalizeOrderStatus(string(updated.Status)), nil
 }
 
-func (s *OrderService) PlaceOrder(ctx context.Context, req dto.PlaceOrderRequest) (utils.OrderAPIResponse, error) {
+// applyProviderStatus moves the order to the state matching the provider's order status.
+// Transitions the state machine rejects (e.g. a stale status) are logged and ignored.
+func (s *OrderService) applyProviderStatus(ctx context.Context, orderID string, result providers.OrderResult, actor string) (model.Order, error) {
+	status := result.OrderStatus
+	if status == "" {
+		log.Printf("[Order] Unknown provider status %q for order %s", result.Status, orderID)
+		return model.Order{}, fmt.Errorf("unknown provider status %q", result.Status)
+	}
+
+	order, err := s.transitionStatus(ctx, orderID, status, actor, "provider status "+result.Status)
+	if err != nil {
+		log.Printf("[Order] Status update for order %s skipped: %v", orderID, err)
+		return order, err
+	}
 
-	tokenResp, err := utils.FetchAccessToken(ctx)
+	// A direct sale is captured in full; pre-auth captures record their own amount first.
+	if status == model.OrderStatusProcessed {
+		if err := s.repo.RecordCapture(ctx, orderID, order.Amount); err != nil {
+			return order, err
+		}
+	}
+	return order, nil
+}
+
+// transitionStatus moves the order through the state machine and emits an
+// "order.<status>" webhook when the status actually changed.
+func (s *OrderService) transitionStatus(ctx context.Context, orderID string, to model.OrderStatus, actor, reason string) (model.Order, error) {
+	order, changed, err := s.repo.TransitionStatus(ctx, orderID, to, actor, reason)
 	if err != nil {
-		return utils.OrderAPIResponse{}, err
+		return order, err
+	}
+	if changed {
+		s.webhooks.Emit(ctx, order.CreatedBy, model.WebhookEventOrderPrefix+string(to), order)
+	}
+	return order, nil
+}
+
+// generatedReference returns a merchant reference for a caller that sent none. A
+// request retried with the same Idempotency-Key gets the same reference, so the ledger
+// and the provider reject the repeat instead of applying it twice.
+func generatedReference(ctx context.Context, prefix string) string {
+	key := utils.IdempotencyKeyFromContext(ctx)
+	if key == "" {
+		return prefix + uuid.New().String()[:20]
+	}
+	sum := sha256.Sum256([]byte(key))
+	return prefix + hex.EncodeToString(sum[:])[:20]
+}
+
+// actorFromContext identifies who caused a transition made while serving a request.
+func actorFromContext(ctx context.Context) string {
+	if userID := utils.UserIDFromContext(ctx); userID != "" {
+		return model.UserActor(userID)
 	}
-	jsonPayload, err := helpers.BuildOrderPayload(req)
+	return model.ActorSystem
+}
+
+func (s *OrderService) PlaceOrder(ctx context.Context, req dto.PlaceOrderRequest) (providers.CheckoutSession, error) {
+
+	provider, err := s.providers.Resolve(req.Provider, req.MerchantID)
 	if err != nil {
-		return utils.OrderAPIResponse{}, err
+		return providers.CheckoutSession{}, err
 	}
 
-	orderResp, err := utils.CreateOrderRequest(ctx, tokenResp.AccessToken, jsonPayload)
+	provider, orderResp, err := s.providers.CreateOrder(ctx, provider, req)
 	if err != nil {
-		return utils.OrderAPIResponse{}, err
+		return providers.CheckoutSession{}, err
 	}
 
 	transaction := model.Transaction{
 		MerchantOrderReference: string(rune(req.MerchantOrderReference)),
+		MerchantID:             req.MerchantID,
+		Provider:               provider.Name(),
 		OrderAmount: model.OrderAmount{
 			Value:    req.OrderAmount.Value,
 			Currency: req.OrderAmount.Currency,
@@ -101,116 +193,481 @@ func (s *OrderService) PlaceOrder(ctx context.Context, req dto.PlaceOrderRequest
 	}
 
 	if err := s.transactionRepo.SaveTransaction(ctx, transaction); err != nil {
-		return utils.OrderAPIResponse{}, err
+		return providers.CheckoutSession{}, err
 	}
 
+	now := time.Now()
 	order := model.Order{
 		UserID:                 req.PurchaseDetails.Customer.CustomerID,
+		CreatedBy:              utils.UserIDFromContext(ctx),
 		TransactionReferenceId: orderResp.OrderID,
 		Amount:                 req.OrderAmount.Value,
 		Currency:               req.OrderAmount.Currency,
-		Status:                 "Pending",
-		CreatedAt:              time.Now(),
-		UpdatedAt:              time.Now(),
+		Status:                 model.OrderStatusCreated,
+		StatusHistory: []model.OrderStatusTransition{
+			{To: model.OrderStatusCreated, Actor: actorFromContext(ctx), At: now},
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
 
 	if err := s.repo.SaveOrder(ctx, order); err != nil {
-		return utils.OrderAPIResponse{}, err
+		return providers.CheckoutSession{}, err
 	}
 	// s.FetchAndUpdateTransactionDetails(ctx, orderResp.OrderID)
-	return utils.OrderAPIResponse{
-		Token:       orderResp.Token,
-		OrderID:     orderResp.OrderID,
-		RedirectURL: orderResp.RedirectURL,
-	}, nil
+	return orderResp, nil
 
 }
 
-func (s *OrderService) UpdateOrder(referenceID string, payload *dto.UpdateOrderPayload) error {
+func (s *OrderService) UpdateOrder(ctx context.Context, referenceID string, payload *dto.UpdateOrderPayload) error {
 	if referenceID == "" {
 		return fmt.Errorf("transaction reference ID is required")
 	}
 
+	if payload.Status != "" {
+		status, err := model.ParseOrderStatus(payload.Status)
+		if err != nil {
+			return err
+		}
+		if _, err := s.transitionStatus(ctx, referenceID, status, actorFromContext(ctx), "manual update"); err != nil {
+			return err
+		}
+	}
+
 	return s.repo.UpdateOrder(referenceID, payload)
 }
 
-func (s *OrderService) ProcessRefund(ctx context.Context, req dto.RefundRequest) (dto.PineOrderResponse, error) {
+func (s *OrderService) ProcessRefund(ctx context.Context, req dto.RefundRequest) (providers.OrderResult, error) {
 	// Fetch the order from the database
-	order, err := s.repo.GetOrderByTransactionReferenceId(ctx, req.OrderID)
+	order, err := s.getOrder(ctx, req.OrderID)
 	if err != nil {
-		return dto.PineOrderResponse{}, fmt.Errorf("order not found: %w", err)
+		return providers.OrderResult{}, fmt.Errorf("order not found: %w", err)
+	}
+
+	status := model.NormalizeOrderStatus(string(order.Status))
+	if status != model.OrderStatusProcessed && status != model.OrderStatusPartiallyRefunded {
+		return providers.OrderResult{}, fmt.Errorf("%w: order in status %s cannot be refunded", ErrInvalidOrderState, status)
 	}
 
 	// Validate refund amount
-	if float32(req.OrderAmount) > order.Amount {
-		return dto.PineOrderResponse{}, fmt.Errorf("refund amount exceeds order amount")
+	if req.OrderAmount <= 0 {
+		return providers.OrderResult{}, fmt.Errorf("%w: refund amount must be positive", ErrInvalidAmount)
 	}
 
-	// Fetch access token
-	tokenResp, err := utils.FetchAccessToken(ctx)
+	provider, err := s.providerForOrder(ctx, req.OrderID)
 	if err != nil {
-		return dto.PineOrderResponse{}, fmt.Errorf("failed to fetch access token: %w", err)
+		return providers.OrderResult{}, err
 	}
-	MerchantOrderReferenceID := fmt.Sprintf("TX-%s", uuid.New().String()[:20])
-
-	currency := "INR"
-	key1 := "DD"
-	key2 := "XOF"
+	currency, err := s.orderCurrency(ctx, order)
+	if err != nil {
+		return providers.OrderResult{}, err
+	}
+	amount := money.New(int64(req.OrderAmount), currency)
 
-	// Build refund payload
-	refundPayload := map[string]interface{}{
-		"merchant_order_reference": MerchantOrderReferenceID,
-		"order_amount": map[string]interface{}{
-			"value":    req.OrderAmount,
-			"currency": currency,
-		},
-		"merchant_metadata": map[string]interface{}{
-			"key1":  key1,
-			"key_2": key2,
-		},
+	refundRef := req.MerchantRefundReference
+	if refundRef == "" {
+		refundRef = generatedReference(ctx, "TX-")
 	}
 
-	// Convert payload to JSON
-	jsonPayload, err := json.Marshal(refundPayload)
+	now := time.Now()
+	entry, err := s.refundRepo.CreateEntry(ctx, model.RefundEntry{
+		OrderID:                 req.OrderID,
+		MerchantRefundReference: refundRef,
+		Amount:                  amount.Value,
+		Currency:                amount.Currency,
+		Reason:                  req.Reason,
+		Metadata:                req.Metadata,
+		Status:                  model.RefundStatusRequested,
+		RequestedBy:             actorFromContext(ctx),
+		CreatedAt:               now,
+		UpdatedAt:               now,
+	})
 	if err != nil {
-		return dto.PineOrderResponse{}, fmt.Errorf("failed to marshal refund payload: %w", err)
+		return providers.OrderResult{}, err
+	}
+
+	// Reserve the amount before calling the provider so parallel refunds cannot overdraw the order.
+	if _, err := s.repo.ReserveRefund(ctx, req.OrderID, amount.Value); err != nil {
+		s.markRefundEntryFailed(ctx, entry, err.Error(), false)
+		return providers.OrderResult{}, err
 	}
 
 	// Create refund request
-	refundResponse, err := utils.CreateRefundRequest(ctx, tokenResp.AccessToken, req.OrderID, jsonPayload)
+	refundResponse, err := provider.CreateRefund(ctx, req.OrderID, providers.RefundParams{
+		MerchantRefundReference: refundRef,
+		Amount:                  amount,
+		Metadata:                req.Metadata,
+	})
 	if err != nil {
-		return dto.PineOrderResponse{}, fmt.Errorf("failed to process refund with Pine Labs: %w", err)
+		// Only a definite rejection frees the balance. After a timeout or 5xx the provider
+		// may still have applied the refund, so the entry stays requested for the tracker.
+		if errors.Is(err, providers.ErrProviderRejected) {
+			s.markRefundEntryFailed(ctx, entry, err.Error(), true)
+		} else {
+			log.Printf("[Refund] Refund %s for order %s has an unknown outcome, leaving it to the tracker: %v", refundRef, req.OrderID, err)
+		}
+		return providers.OrderResult{}, fmt.Errorf("failed to process refund with %s: %w", provider.Name(), err)
+	}
+
+	if err := s.repo.SaveRefundResponse(ctx, refundResponse.Raw); err != nil {
+		return providers.OrderResult{}, fmt.Errorf("failed to save refund: %w", err)
 	}
 
-	if err := s.repo.SaveRefundResponse(ctx, refundResponse); err != nil {
-		return dto.PineOrderResponse{}, fmt.Errorf("failed to save refund: %w", err)
+	if err := s.applyRefundStatus(ctx, entry, refundResponse.RefundID, refundResponse.Status, []model.RefundStatus{model.RefundStatusRequested}); err != nil {
+		return providers.OrderResult{}, err
 	}
 
 	// Fetch order details again to get refunds
-	orderDetailsResp, err := utils.GetOrderDetails(ctx, tokenResp.AccessToken, req.OrderID)
+	orderDetailsResp, err := provider.GetOrder(ctx, req.OrderID)
+	if err != nil {
+		return providers.OrderResult{}, fmt.Errorf("failed to fetch order details after refund: %w", err)
+	}
+
+	// Save refund
+	if err := s.repo.SaveRefund(ctx, refundSnapshot(orderDetailsResp, order.ID)); err != nil {
+		return providers.OrderResult{}, fmt.Errorf("failed to save refund: %w", err)
+	}
+
+	return orderDetailsResp, nil
+}
+
+// orderCurrency returns the currency the order was placed in, cross-checked against
+// the stored provider transaction.
+func (s *OrderService) orderCurrency(ctx context.Context, order model.Order) (string, error) {
+	currency := order.Currency
+
+	tx, err := s.transactionRepo.GetTransactionByPineOrderID(ctx, order.TransactionReferenceId)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return "", fmt.Errorf("failed to load transaction for order %s: %w", order.TransactionReferenceId, err)
+	}
+	if txCurrency := tx.OrderAmount.Currency; txCurrency != "" {
+		if currency == "" {
+			currency = txCurrency
+		} else if !strings.EqualFold(currency, txCurrency) {
+			return "", fmt.Errorf("%w: order currency %s does not match transaction currency %s",
+				ErrInvalidOrderState, currency, txCurrency)
+		}
+	}
+
+	if err := money.ValidateCurrency(strings.ToUpper(currency)); err != nil {
+		return "", fmt.Errorf("order %s has no usable currency: %w", order.TransactionReferenceId, err)
+	}
+	return strings.ToUpper(currency), nil
+}
+
+// CaptureOrder captures a pre-authorized order, in full or for part of the authorized amount.
+func (s *OrderService) CaptureOrder(ctx context.Context, orderID string, req dto.CaptureRequest) (providers.OrderResult, error) {
+	order, err := s.getAuthorizedOrder(ctx, orderID)
+	if err != nil {
+		return providers.OrderResult{}, err
+	}
+
+	amount := req.Amount
+	if amount == 0 {
+		amount = order.Amount
+	}
+	if amount < 0 || amount > order.Amount {
+		return providers.OrderResult{}, fmt.Errorf("%w: capture amount must be between 0 and %s", ErrInvalidAmount, money.New(int64(order.Amount), order.Currency))
+	}
+
+	captureRef := req.MerchantCaptureReference
+	if captureRef == "" {
+		captureRef = generatedReference(ctx, "CP-")
+	}
+
+	provider, err := s.providerForOrder(ctx, orderID)
+	if err != nil {
+		return providers.OrderResult{}, err
+	}
+
+	resp, err := provider.CaptureOrder(ctx, orderID, providers.CaptureParams{
+		MerchantCaptureReference: captureRef,
+		Amount:                   money.New(int64(amount), order.Currency),
+	})
+	if err != nil {
+		return providers.OrderResult{}, fmt.Errorf("failed to capture order with %s: %w", provider.Name(), err)
+	}
+
+	if err := s.saveProviderOrder(ctx, orderID, provider, resp); err != nil {
+		return providers.OrderResult{}, err
+	}
+
+	// Overwrite whatever a callback or reconciliation recorded in the meantime.
+	if err := s.repo.SetCapturedAmount(ctx, orderID, amount); err != nil {
+		return providers.OrderResult{}, err
+	}
+
+	if _, err := s.transitionStatus(ctx, orderID, model.OrderStatusProcessed, actorFromContext(ctx), "capture "+captureRef); err != nil {
+		return providers.OrderResult{}, fmt.Errorf("failed to update order status after capture: %w", err)
+	}
+
+	return resp, nil
+}
+
+// VoidOrder releases a pre-authorization without capturing it.
+func (s *OrderService) VoidOrder(ctx context.Context, orderID string) (providers.OrderResult, error) {
+	if _, err := s.getAuthorizedOrder(ctx, orderID); err != nil {
+		return providers.OrderResult{}, err
+	}
+
+	provider, err := s.providerForOrder(ctx, orderID)
+	if err != nil {
+		return providers.OrderResult{}, err
+	}
+
+	resp, err := provider.CancelOrder(ctx, orderID)
+	if err != nil {
+		return providers.OrderResult{}, fmt.Errorf("failed to void order with %s: %w", provider.Name(), err)
+	}
+
+	if err := s.saveProviderOrder(ctx, orderID, provider, resp); err != nil {
+		return providers.OrderResult{}, err
+	}
+
+	if _, err := s.transitionStatus(ctx, orderID, model.OrderStatusCancelled, actorFromContext(ctx), "void"); err != nil {
+		return providers.OrderResult{}, fmt.Errorf("failed to update order status after void: %w", err)
+	}
+
+	return resp, nil
+}
+
+// CancelOrder cancels an order the customer has not paid for yet.
+func (s *OrderService) CancelOrder(ctx context.Context, orderID string) (providers.OrderResult, error) {
+	order, err := s.getOrder(ctx, orderID)
+	if err != nil {
+		return providers.OrderResult{}, err
+	}
+	if status := model.NormalizeOrderStatus(string(order.Status)); !status.IsUnpaid() {
+		return providers.OrderResult{}, fmt.Errorf("%w: order is %s and can no longer be cancelled", ErrInvalidOrderState, status)
+	}
+
+	provider, err := s.providerForOrder(ctx, orderID)
+	if err != nil {
+		return providers.OrderResult{}, err
+	}
+
+	resp, err := provider.CancelOrder(ctx, orderID)
+	if err != nil {
+		return providers.OrderResult{}, fmt.Errorf("failed to cancel order with %s: %w", provider.Name(), err)
+	}
+
+	if err := s.saveProviderOrder(ctx, orderID, provider, resp); err != nil {
+		return providers.OrderResult{}, err
+	}
+
+	if _, err := s.transitionStatus(ctx, orderID, model.OrderStatusCancelled, actorFromContext(ctx), "cancel"); err != nil {
+		return providers.OrderResult{}, fmt.Errorf("failed to update order status after cancel: %w", err)
+	}
+
+	return resp, nil
+}
+
+// ExpireOrder marks an unpaid order expired in both orders and transactions. The
+// provider is checked first so a payment whose callback was lost is not expired;
+// expired reports whether the order was actually expired.
+func (s *OrderService) ExpireOrder(ctx context.Context, orderID string) (bool, error) {
+	_, status, err := s.SyncOrderWithProvider(ctx, orderID, model.ActorExpiry)
 	if err != nil {
-		return dto.PineOrderResponse{}, fmt.Errorf("failed to fetch order details after refund: %w", err)
+		return false, fmt.Errorf("failed to sync order before expiry: %w", err)
+	}
+	if !status.IsUnpaid() {
+		return false, nil
 	}
 
-	// Update order amount and status
-	order.Amount -= float32(req.OrderAmount)
-	if order.Amount == 0 {
-		order.Status = "Refunded"
+	// Stop the checkout link from accepting a late payment. Providers may already
+	// have expired the order on their side, so a failure here is not fatal.
+	if provider, err := s.providerForOrder(ctx, orderID); err == nil {
+		if _, err := provider.CancelOrder(ctx, orderID); err != nil {
+			log.Printf("[Expiry] Provider cancel for order %s failed: %v", orderID, err)
+		}
+	}
+
+	if _, err := s.transitionStatus(ctx, orderID, model.OrderStatusExpired, model.ActorExpiry, "unpaid after TTL"); err != nil {
+		if errors.Is(err, repository.ErrInvalidStatusTransition) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := s.transactionRepo.UpdateTransactionStatus(ctx, orderID, "EXPIRED"); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+func (s *OrderService) getAuthorizedOrder(ctx context.Context, orderID string) (model.Order, error) {
+	order, err := s.getOrder(ctx, orderID)
+	if err != nil {
+		return model.Order{}, err
+	}
+	if status := model.NormalizeOrderStatus(string(order.Status)); status != model.OrderStatusAuthorized {
+		return model.Order{}, fmt.Errorf("%w: order is %s, not authorized", ErrInvalidOrderState, status)
+	}
+	return order, nil
+}
+
+// saveProviderOrder rewrites the stored transaction, including its payments, from a provider response.
+func (s *OrderService) saveProviderOrder(ctx context.Context, orderID string, provider providers.PaymentProvider, resp providers.OrderResult) error {
+	transactionModel := resp.Transaction
+	transactionModel.Provider = provider.Name()
+
+	if err := s.transactionRepo.UpdateTransactionByOrderID(ctx, orderID, transactionModel); err != nil {
+		return fmt.Errorf("failed to update transaction: %w", err)
+	}
+	return nil
+}
+
+// refundSnapshot builds the refund snapshot read by GetOrderDetails from a provider
+// order, tying each refund to the stored order.
+func refundSnapshot(resp providers.OrderResult, orderID primitive.ObjectID) model.Transaction {
+	snapshot := resp.Transaction
+	snapshot.Refunds = make([]model.Refund, 0, len(resp.Refunds))
+	for _, refund := range resp.Refunds {
+		refund.TransactionID = orderID
+		snapshot.Refunds = append(snapshot.Refunds, refund)
+	}
+	return snapshot
+}
+
+// canAccessOrder reports whether the caller may see and act on order. Customers reach
+// only the orders they placed. Support users reach every order, including those stored
+// before createdBy was recorded, which no customer owns.
+func canAccessOrder(ctx context.Context, order model.Order) bool {
+	if utils.UserRoleFromContext(ctx) == model.UserRoleSupport {
+		return true
+	}
+	return order.CreatedBy != "" && order.CreatedBy == utils.UserIDFromContext(ctx)
+}
+
+// getOrder loads an order the caller may access by its provider order ID. Orders the
+// caller may not access are reported as not found.
+func (s *OrderService) getOrder(ctx context.Context, orderID string) (model.Order, error) {
+	order, err := s.repo.GetOrderByTransactionReferenceId(ctx, orderID)
+	if err != nil {
+		return model.Order{}, err
+	}
+	if !canAccessOrder(ctx, order) {
+		return model.Order{}, repository.ErrOrderNotFound
+	}
+	return order, nil
+}
+
+// findOrder loads an order the caller may access by its provider order ID or its Mongo
+// ID. Orders the caller may not access are reported as not found.
+func (s *OrderService) findOrder(ctx context.Context, id string) (model.Order, error) {
+	var (
+		order model.Order
+		err   error
+	)
+	if objectID, perr := primitive.ObjectIDFromHex(id); perr == nil {
+		order, err = s.repo.GetOrderByObjectID(ctx, objectID)
 	} else {
-		order.Status = "Partially Refunded"
+		order, err = s.repo.GetOrderByTransactionReferenceId(ctx, id)
+	}
+	if err != nil {
+		return model.Order{}, err
+	}
+	if !canAccessOrder(ctx, order) {
+		return model.Order{}, repository.ErrOrderNotFound
 	}
-	order.UpdatedAt = time.Now()
+	return order, nil
+}
 
-	if err := s.repo.UpdateOrderRefund(ctx, order); err != nil {
-		return dto.PineOrderResponse{}, fmt.Errorf("failed to update order after refund: %w", err)
+// GetOrderDetails returns an order with its transaction, payments and refunds.
+// id may be the provider order ID or the order's Mongo ID.
+func (s *OrderService) GetOrderDetails(ctx context.Context, id string) (dto.OrderDetailResponse, error) {
+	order, err := s.findOrder(ctx, id)
+	if err != nil {
+		return dto.OrderDetailResponse{}, err
 	}
 
-	refundModel := helpers.MapRefundsToTransactionModel(orderDetailsResp, order.ID)
+	resp := dto.OrderDetailResponse{
+		Order:    order,
+		Payments: []model.Payment{},
+		Refunds:  []model.Refund{},
+	}
 
-	// Save refund
-	if err := s.repo.SaveRefund(ctx, refundModel); err != nil {
-		return dto.PineOrderResponse{}, fmt.Errorf("failed to save refund: %w", err)
+	tx, err := s.transactionRepo.GetTransactionByPineOrderID(ctx, order.TransactionReferenceId)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return dto.OrderDetailResponse{}, fmt.Errorf("failed to load transaction: %w", err)
+	}
+	if err == nil {
+		resp.Transaction = &tx
+		resp.Payments = append(resp.Payments, tx.Payments...)
+		resp.Refunds = append(resp.Refunds, tx.Refunds...)
+	}
+
+	// Refund snapshots are taken after each refund and are more recent than the transaction.
+	snapshot, err := s.repo.GetLatestRefundSnapshot(ctx, order.TransactionReferenceId)
+	if err != nil {
+		return dto.OrderDetailResponse{}, err
+	}
+	if snapshot != nil && len(snapshot.Refunds) > 0 {
+		resp.Refunds = snapshot.Refunds
+	}
+
+	resp.RefundLedger, err = s.refundRepo.GetEntriesByOrderID(ctx, order.TransactionReferenceId)
+	if err != nil {
+		return dto.OrderDetailResponse{}, err
+	}
+
+	return resp, nil
+}
+
+// SearchOrders returns one page of the orders matching params that the caller may
+// access, with the customer of each order attached.
+func (s *OrderService) SearchOrders(ctx context.Context, params dto.OrderSearchParams) (dto.OrderListResponse, error) {
+	params.CreatedBy = ""
+	if utils.UserRoleFromContext(ctx) != model.UserRoleSupport {
+		params.CreatedBy = utils.UserIDFromContext(ctx)
+	}
+
+	// Email and mobile live on transactions, so resolve them to order IDs first.
+	var orderIDs []string
+	if params.Email != "" || params.Mobile != "" {
+		ids, err := s.transactionRepo.FindOrderIDsByCustomer(ctx, params.Email, params.Mobile)
+		if err != nil {
+			return dto.OrderListResponse{}, err
+		}
+		if len(ids) == 0 {
+			return dto.OrderListResponse{Orders: []dto.OrderListItem{}}, nil
+		}
+		orderIDs = ids
+	}
+
+	orders, err := s.repo.SearchOrders(ctx, params, orderIDs)
+	if err != nil {
+		return dto.OrderListResponse{}, err
+	}
+
+	pageIDs := make([]string, 0, len(orders))
+	for _, o := range orders {
+		pageIDs = append(pageIDs, o.TransactionReferenceId)
+	}
+
+	customers := make(map[string]model.Customer, len(orders))
+	if len(pageIDs) > 0 {
+		txs, err := s.transactionRepo.GetTransactionsByPineOrderIDs(ctx, pageIDs)
+		if err != nil {
+			return dto.OrderListResponse{}, err
+		}
+		for _, tx := range txs {
+			customers[tx.PineOrderID] = tx.PurchaseDetails.Customer
+		}
+	}
+
+	resp := dto.OrderListResponse{Orders: make([]dto.OrderListItem, 0, len(orders))}
+	for _, o := range orders {
+		item := dto.OrderListItem{Order: o}
+		if customer, ok := customers[o.TransactionReferenceId]; ok {
+			item.Customer = &customer
+		}
+		resp.Orders = append(resp.Orders, item)
+	}
+
+	if int64(len(orders)) == params.Limit {
+		resp.NextCursor = orders[len(orders)-1].ID.Hex()
 	}
 
-	return *orderDetailsResp, nil
+	return resp, nil
 }
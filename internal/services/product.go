@@ -4,30 +4,38 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"strings"
+	"strconv"
 	"sync"
 	"time"
 
-	"github.com/aakritigkmit/payment-gateway/internal/constants"
 	"github.com/aakritigkmit/payment-gateway/internal/dto"
 	"github.com/aakritigkmit/payment-gateway/internal/model"
 	"github.com/aakritigkmit/payment-gateway/internal/repository"
 	"github.com/aakritigkmit/payment-gateway/internal/utils"
 	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/time/rate"
 )
 
 type ProductService struct {
 	productRepo            *repository.ProductRepo
+	priceHistoryRepo       *repository.ProductPriceHistoryRepo
+	syncProfileRepo        *repository.SyncProfileRepo
 	productTransactionRepo *repository.ProductTransactionRepo
 	productOrderRepo       *repository.ProductOrderRepo
+	dtOne                  *utils.DTOneClient
+	webhooks               *WebhookService
 }
 
-func NewProductService(productRepo *repository.ProductRepo, productTransactionRepo *repository.ProductTransactionRepo, productOrderRepo *repository.ProductOrderRepo) *ProductService {
+func NewProductService(productRepo *repository.ProductRepo, priceHistoryRepo *repository.ProductPriceHistoryRepo, syncProfileRepo *repository.SyncProfileRepo, productTransactionRepo *repository.ProductTransactionRepo, productOrderRepo *repository.ProductOrderRepo, dtOne *utils.DTOneClient, webhooks *WebhookService) *ProductService {
 	return &ProductService{
 		productRepo:            productRepo,
+		priceHistoryRepo:       priceHistoryRepo,
+		syncProfileRepo:        syncProfileRepo,
 		productTransactionRepo: productTransactionRepo,
 		productOrderRepo:       productOrderRepo,
+		dtOne:                  dtOne,
+		webhooks:               webhooks,
 	}
 }
 
@@ -168,7 +176,9 @@ func NewProductService(productRepo *repository.ProductRepo, productTransactionRe
 // 	return nil
 // }
 
-func (s *ProductService) SyncProducts(ctx context.Context) error {
+// SyncProducts pulls the given profiles from DT One and upserts their products,
+// counting products in tracker's progress. tracker may be nil.
+func (s *ProductService) SyncProducts(ctx context.Context, profiles []model.SyncProfile, tracker *JobTracker) error {
 	startTime := time.Now()
 
 	const (
@@ -176,36 +186,35 @@ func (s *ProductService) SyncProducts(ctx context.Context) error {
 		fetchConcurrency = 5
 	)
 
-	log.Println("[Sync] Starting product sync...")
-
-	baseFilter := dto.ProductSyncRequest{
-		ServiceID: constants.ProductServiceIDs.GiftCards,
-		Type:      constants.ProductTypes.FixedValuePinPurchase,
-	}
+	log.Printf("[Sync] Starting product sync for %d profiles...", len(profiles))
 
 	type result struct {
-		operator string
+		profile  model.SyncProfile
 		products []model.Product
+		// complete is false when any page failed, in which case products missing
+		// from this run must not be deactivated.
+		complete bool
 		err      error
 	}
 
 	var wg sync.WaitGroup
-	resultChan := make(chan result, len(constants.ProductOperatorId))
+	resultChan := make(chan result, len(profiles))
 
-	for operator, operatorID := range constants.ProductOperatorId {
-		log.Printf("[Sync] Starting fetch for operator: %s (ID: %d)", operator, operatorID)
+	for _, profile := range profiles {
+		log.Printf("[Sync] Starting fetch for profile: %s", profile.Name)
 		wg.Add(1)
-		go func(operator string, operatorID int) {
+		go func(profile model.SyncProfile) {
 			defer wg.Done()
 
-			filter := baseFilter
-			filter.OperatorID = operatorID
+			operator := profile.Name
+			filter := syncFilter(profile)
 
 			log.Printf("[Sync:%s] Fetching page 1 to determine total pages...", operator)
-			page1, totalPages, err := utils.FetchDTOneProducts(ctx, 1, perPage, filter)
+			page1, totalPages, err := s.dtOne.FetchProducts(ctx, 1, perPage, filter)
 			if err != nil {
 				log.Printf("[Sync:%s] Initial fetch failed: %v", operator, err)
-				resultChan <- result{operator: operator, err: fmt.Errorf("initial fetch failed: %w", err)}
+				tracker.RecordFetchError(FetchPageError{Page: 1, ErrorMsg: fmt.Sprintf("%s: %v", operator, err)})
+				resultChan <- result{profile: profile, err: fmt.Errorf("initial fetch failed: %w", err)}
 				return
 			}
 
@@ -213,6 +222,7 @@ func (s *ProductService) SyncProducts(ctx context.Context) error {
 
 			all := make([]model.Product, 0, totalPages*perPage)
 			all = append(all, page1...)
+			tracker.AddTotal(len(page1))
 
 			pageChan := make(chan int, totalPages-1)
 			for p := 2; p <= totalPages; p++ {
@@ -222,6 +232,7 @@ func (s *ProductService) SyncProducts(ctx context.Context) error {
 
 			var fetchWg sync.WaitGroup
 			var prodMu sync.Mutex
+			complete := true
 
 			for i := 0; i < fetchConcurrency; i++ {
 				fetchWg.Add(1)
@@ -229,47 +240,100 @@ func (s *ProductService) SyncProducts(ctx context.Context) error {
 					defer fetchWg.Done()
 					for page := range pageChan {
 						log.Printf("[Sync:%s] Worker %d fetching page %d", operator, workerID, page)
-						products, _, err := utils.FetchDTOneProducts(ctx, page, perPage, filter)
+						products, _, err := s.dtOne.FetchProducts(ctx, page, perPage, filter)
 						if err != nil {
 							log.Printf("[Sync:%s] Fetch page %d failed: %v", operator, page, err)
+							tracker.RecordFetchError(FetchPageError{Page: page, ErrorMsg: fmt.Sprintf("%s: %v", operator, err)})
+							prodMu.Lock()
+							complete = false
+							prodMu.Unlock()
 							continue
 						}
 						prodMu.Lock()
 						all = append(all, products...)
 						prodMu.Unlock()
+						tracker.AddTotal(len(products))
 					}
 				}(i + 1)
 			}
 
 			fetchWg.Wait()
 			log.Printf("[Sync:%s] All pages fetched. Total products: %d", operator, len(all))
-			resultChan <- result{operator: operator, products: all, err: nil}
-		}(operator, operatorID)
+			resultChan <- result{profile: profile, products: all, complete: complete}
+		}(profile)
 	}
 
 	go func() {
 		wg.Wait()
 		close(resultChan)
-		log.Println("[Sync] All operator fetches complete.")
+		log.Println("[Sync] All profile fetches complete.")
 	}()
 
-	var reportData []model.Product
+	var (
+		reportData                                        []model.Product
+		created, updated, unchanged, priceChanges, failed int
+		deactivated                                       int64
+	)
 	for res := range resultChan {
 		if res.err != nil {
-			log.Printf("[Sync] Error for operator %s: %v", res.operator, res.err)
+			log.Printf("[Sync] Error for profile %s: %v", res.profile.Name, res.err)
 			continue
 		}
 
-		log.Printf("[Sync] Persisting %d products for %s", len(res.products), res.operator)
+		log.Printf("[Sync] Persisting %d products for %s", len(res.products), res.profile.Name)
+		saveFailed := false
 		for _, p := range res.products {
-			if err := s.productRepo.FindOrCreateProduct(ctx, p); err != nil {
-				log.Printf("[Sync] DB save error for %s product ID %d: %v", res.operator, p.UniqueId, err)
+			if ctx.Err() != nil {
+				log.Printf("[Sync] Stopped: %v", ctx.Err())
+				return ctx.Err()
+			}
+
+			upsert, err := s.productRepo.UpsertProduct(ctx, p, startTime)
+			if err != nil {
+				failed++
+				saveFailed = true
+				log.Printf("[Sync] DB save error for %s product ID %d: %v", res.profile.Name, p.UniqueId, err)
+				tracker.Failed(1)
+				tracker.RecordSaveError(ProductSaveError{ProductID: p.UniqueId, ErrorMsg: err.Error()})
+				continue
+			}
+			tracker.Succeeded(1)
+
+			switch {
+			case upsert.Created:
+				created++
+			case upsert.Updated:
+				updated++
+			default:
+				unchanged++
+			}
+			if upsert.PriceChanged {
+				priceChanges++
+				s.recordPriceChange(ctx, p, upsert.Previous, startTime)
 			}
 		}
 
+		if res.complete && !saveFailed {
+			n, err := s.productRepo.DeactivateMissing(ctx, syncScope(res.profile), startTime)
+			if err != nil {
+				log.Printf("[Sync] Deactivation failed for %s: %v", res.profile.Name, err)
+			} else if n > 0 {
+				deactivated += n
+				log.Printf("[Sync] Deactivated %d products no longer offered for %s", n, res.profile.Name)
+			}
+			if err := s.syncProfileRepo.MarkSynced(ctx, res.profile.ID, startTime, len(res.products)); err != nil {
+				log.Printf("[Sync] %v", err)
+			}
+		} else {
+			log.Printf("[Sync] Skipping deactivation for %s: sync was incomplete", res.profile.Name)
+		}
+
 		reportData = append(reportData, res.products...)
 	}
 
+	log.Printf("[Sync] Created: %d, Updated: %d, Unchanged: %d, Price changes: %d, Deactivated: %d, Failed: %d",
+		created, updated, unchanged, priceChanges, deactivated, failed)
+
 	log.Println("[Sync] Generating Excel report for synced products...")
 	if err := ExportProductsToExcel(reportData); err != nil {
 		log.Printf("[Sync] Excel generation failed: %v", err)
@@ -283,7 +347,27 @@ func (s *ProductService) SyncProducts(ctx context.Context) error {
 	return nil
 }
 
-func (s *ProductService) GenerateProductFetchReport(ctx context.Context, filter dto.ProductSyncRequest) error {
+// recordPriceChange writes a price history entry. A failure is logged rather than
+// failing the sync, since the product itself has already been saved.
+func (s *ProductService) recordPriceChange(ctx context.Context, product model.Product, previous *model.Product, changedAt time.Time) {
+	entry := model.ProductPriceHistory{
+		ProductID: product.UniqueId,
+		NewPrices: product.Prices,
+		NewRates:  product.Rates,
+		ChangedAt: changedAt,
+	}
+	if previous != nil {
+		entry.OldPrices = &previous.Prices
+		entry.OldRates = &previous.Rates
+	}
+	if err := s.priceHistoryRepo.Insert(ctx, entry); err != nil {
+		log.Printf("[Sync] %v", err)
+	}
+}
+
+// GenerateProductFetchReport exports the DT One products matching filter to Excel,
+// counting pages in tracker's progress. tracker may be nil.
+func (s *ProductService) GenerateProductFetchReport(ctx context.Context, filter dto.ProductSyncRequest, tracker *JobTracker) error {
 	startTime := time.Now()
 
 	const perPage = 100
@@ -293,12 +377,13 @@ func (s *ProductService) GenerateProductFetchReport(ctx context.Context, filter
 	log.Println("[Report] Starting DT One product report generation...")
 
 	// Step 1: Initial fetch to get total pages
-	_, totalPages, err := utils.FetchDTOneProducts(ctx, 1, perPage, filter)
+	_, totalPages, err := s.dtOne.FetchProducts(ctx, 1, perPage, filter)
 	if err != nil {
 		log.Printf("[Report] Initial fetch failed: %v", err)
 		return err
 	}
 	log.Printf("[Report] Total pages to fetch: %d", totalPages)
+	tracker.AddTotal(totalPages)
 
 	productChan := make(chan model.Product, 5000)
 	pageChan := make(chan int, totalPages)
@@ -326,11 +411,14 @@ func (s *ProductService) GenerateProductFetchReport(ctx context.Context, filter
 					log.Printf("[Fetcher %d] Context cancelled. Exiting.", workerID)
 					return
 				default:
-					products, _, err := utils.FetchDTOneProducts(ctx, page, perPage, filter)
+					products, _, err := s.dtOne.FetchProducts(ctx, page, perPage, filter)
 					if err != nil {
 						log.Printf("[Fetcher %d] Page %d error: %v", workerID, page, err)
+						tracker.Failed(1)
+						tracker.RecordFetchError(FetchPageError{Page: page, ErrorMsg: err.Error()})
 						continue
 					}
+					tracker.Succeeded(1)
 					log.Printf("[Fetcher %d] Fetched %d products from page %d", workerID, len(products), page)
 					for _, p := range products {
 						productChan <- p
@@ -360,6 +448,9 @@ func (s *ProductService) GenerateProductFetchReport(ctx context.Context, filter
 	}
 
 	log.Printf("[Report] Fetched total %d products in %v", len(allProducts), time.Since(start))
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 
 	// Step 6: Generate Excel report
 	log.Println("[Report] Generating Excel report...")
@@ -374,7 +465,9 @@ func (s *ProductService) GenerateProductFetchReport(ctx context.Context, filter
 	return nil
 }
 
-func (s *ProductService) GenerateProductReportByIDs(ctx context.Context, productIDs []int) error {
+// GenerateProductReportByIDs exports the given synced products to Excel, counting
+// requested IDs in tracker's progress. tracker may be nil.
+func (s *ProductService) GenerateProductReportByIDs(ctx context.Context, productIDs []int, tracker *JobTracker) error {
 	log.Println("[ReportByIDs] Starting product report generation by IDs...")
 	start := time.Now()
 
@@ -385,6 +478,11 @@ func (s *ProductService) GenerateProductReportByIDs(ctx context.Context, product
 	}
 
 	log.Printf("[ReportByIDs] Retrieved %d products from DB", len(products))
+	tracker.AddTotal(len(productIDs))
+	tracker.Succeeded(len(products))
+	if missing := len(productIDs) - len(products); missing > 0 {
+		tracker.Failed(missing)
+	}
 
 	log.Println("[ReportByIDs] Generating Excel...")
 	if err := ExportProductsToExcel(products); err != nil {
@@ -396,34 +494,135 @@ func (s *ProductService) GenerateProductReportByIDs(ctx context.Context, product
 	return nil
 }
 
-func (s *ProductService) CreateAndSaveTransaction(ctx context.Context, req dto.CreateTransactionRequest) error {
-	// Step 1: Create transaction via DT One
-	if err := utils.CreateDTOneTransaction(ctx, req.ExternalID, req.ProductID, req.MobileNumber); err != nil {
-		return fmt.Errorf("failed to create transaction: %w", err)
+// GenerateCatalogReport exports the active synced catalog to Excel and returns the
+// number of products exported.
+func (s *ProductService) GenerateCatalogReport(ctx context.Context) (int, error) {
+	products, err := s.productRepo.FindActiveProducts(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := ExportProductsToExcel(products); err != nil {
+		return 0, err
+	}
+	return len(products), nil
+}
+
+// SearchProducts returns one page of the synced catalog.
+func (s *ProductService) SearchProducts(ctx context.Context, params dto.ProductSearchParams) (dto.ProductListResponse, error) {
+	products, err := s.productRepo.SearchProducts(ctx, params)
+	if err != nil {
+		return dto.ProductListResponse{}, err
+	}
+
+	resp := dto.ProductListResponse{Products: products}
+	if int64(len(products)) == params.Limit {
+		resp.NextCursor = products[len(products)-1].ID.Hex()
+	}
+	return resp, nil
+}
+
+// GetProduct returns a single product. id may be the DT One product ID or the product's Mongo ID.
+func (s *ProductService) GetProduct(ctx context.Context, id string) (model.Product, error) {
+	if uniqueID, err := strconv.Atoi(id); err == nil {
+		return s.productRepo.GetProductByUniqueID(ctx, uniqueID)
+	}
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.Product{}, repository.ErrProductNotFound
+	}
+	return s.productRepo.GetProductByObjectID(ctx, objectID)
+}
+
+// GetPriceHistory returns the most recent price changes of a product, newest first.
+func (s *ProductService) GetPriceHistory(ctx context.Context, id string, limit int64) ([]model.ProductPriceHistory, error) {
+	product, err := s.GetProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.priceHistoryRepo.FindByProduct(ctx, product.UniqueId, limit)
+}
+
+// CreateAndSaveTransaction creates a DT One transaction and stores it, returning the
+// stored transaction. DT One's response is stored as soon as the transaction exists, so
+// it is tracked even if refreshing it afterwards fails. Transactions created with
+// auto_confirm false wait for ConfirmTransaction until their ConfirmationExpirationDate.
+func (s *ProductService) CreateAndSaveTransaction(ctx context.Context, req dto.CreateTransactionRequest) (model.ProductTransaction, error) {
+	txReq, err := s.buildTransactionRequest(ctx, req.ExternalID, req.ProductID, req.MobileNumber, req.TransactionAmount, req.PartyFields)
+	if err != nil {
+		return model.ProductTransaction{}, err
+	}
+	if req.AutoConfirm != nil {
+		txReq.AutoConfirm = *req.AutoConfirm
 	}
 
-	// Step 2: Fetch transaction details
-	productTransactions, err := utils.FetchDTOneTransactionByExternalID(ctx, req.ExternalID)
+	// Step 1: Create transaction via DT One
+	created, err := s.dtOne.CreateTransaction(ctx, txReq)
 	if err != nil {
-		return fmt.Errorf("failed to fetch transaction: %w", err)
+		return model.ProductTransaction{}, fmt.Errorf("failed to create transaction: %w", err)
+	}
+
+	// Step 2: Save DT One's response right away
+	if created.ExternalID == "" {
+		created.ExternalID = req.ExternalID
+	}
+	created.CreatedAt = time.Now()
+	created.UpdatedAt = created.CreatedAt
+	created.CreatedBy = utils.UserIDFromContext(ctx)
+	created.AutoConfirm = &txReq.AutoConfirm
+	if err := s.productTransactionRepo.SaveProductTransaction(ctx, created); err != nil {
+		return model.ProductTransaction{}, fmt.Errorf("transaction %s was created but not saved: %w", created.ExternalID, err)
 	}
 
-	// Step 3: Save to DB using ProductTransactionRepo
+	// Step 3: Refresh it with the full details, best effort
+	productTransactions, err := s.dtOne.FetchTransactionByExternalID(ctx, created.ExternalID)
+	if err != nil {
+		log.Printf("[Transactions] Fetching transaction %s failed: %v", created.ExternalID, err)
+		return created, nil
+	}
 	for _, tx := range productTransactions {
-		if err := s.productTransactionRepo.SaveProductTransaction(ctx, tx); err != nil {
-			log.Printf("error saving product transaction: %v", err)
+		if tx.ID != created.ID {
+			continue
 		}
+		refreshed, applied, err := s.saveTransactionUpdate(ctx, tx)
+		if err != nil {
+			log.Printf("[Transactions] Refreshing transaction %s failed: %v", created.ExternalID, err)
+			break
+		}
+		if applied {
+			refreshed.UpdatedAt = time.Now()
+			refreshed.CreatedBy = created.CreatedBy
+			refreshed.AutoConfirm = created.AutoConfirm
+			created = refreshed
+		}
+		break
 	}
 
-	return nil
+	return created, nil
 }
 
 func (s *ProductService) InitBulkProductTransaction(ctx context.Context, req dto.BulkTransactionRequest) (string, error) {
+	// Reject line items that do not fit their product before accepting the order.
+	if err := s.validateBulkLineItems(ctx, req); err != nil {
+		return "", err
+	}
+
 	orderId := uuid.New().String()
 
+	lineItems := make([]model.ProductOrderLineItem, 0, len(req.LineItems))
+	for _, item := range req.LineItems {
+		lineItems = append(lineItems, model.ProductOrderLineItem{
+			ProductID:         item.ProductID,
+			Quantity:          item.Quantity,
+			SourceAmount:      item.SourceAmount,
+			DestinationAmount: item.DestinationAmount,
+		})
+	}
+
 	productOrder := model.ProductPin{
 		OrderID:     orderId,
 		ProductPins: []model.ProductPinItem{}, // initially empty, to be filled later
+		UserID:      utils.UserIDFromContext(ctx),
+		LineItems:   lineItems,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -438,10 +637,17 @@ func (s *ProductService) ProcessBulkProductTransactionAsync(ctx context.Context,
 	startTime := time.Now()
 
 	log.Printf("[INFO] Started processing bulk transaction for OrderID: %s", orderId)
+	defer func() {
+		if err := s.productOrderRepo.MarkFinished(context.Background(), orderId, time.Now()); err != nil {
+			log.Printf("[ERROR] %v", err)
+		}
+	}()
 
 	type task struct {
 		LineItem   dto.LineItem
+		Index      int
 		ExternalID string
+		Request    dto.DTOneTransactionRequest
 	}
 
 	const numWorkers = 5
@@ -469,10 +675,13 @@ func (s *ProductService) ProcessBulkProductTransactionAsync(ctx context.Context,
 			for t := range taskChan {
 				log.Printf("[DEBUG] [Worker %d] Handling task ExternalID: %s, ProductID: %d", workerID, t.ExternalID, t.LineItem.ProductID)
 
+				index := t.Index
 				txRecord := model.ProductTransaction{
-					ExternalID: t.ExternalID,
-					CreatedAt:  time.Now(),
-					UpdatedAt:  time.Now(),
+					ExternalID:    t.ExternalID,
+					OrderID:       orderId,
+					LineItemIndex: &index,
+					CreatedAt:     time.Now(),
+					UpdatedAt:     time.Now(),
 				}
 				if err := s.productTransactionRepo.SaveProductTransaction(ctx, txRecord); err != nil {
 					log.Printf("[ERROR] [Worker %d] Initial save failed for %s: %v", workerID, t.ExternalID, err)
@@ -486,10 +695,8 @@ func (s *ProductService) ProcessBulkProductTransactionAsync(ctx context.Context,
 					continue
 				}
 
-				err := retryOn429(func() error {
-					return utils.CreateDTOneTransaction(ctx, t.ExternalID, t.LineItem.ProductID, req.MobileNumber)
-				})
-				if err != nil {
+				// The client already retries 429s, honouring Retry-After.
+				if _, err := s.dtOne.CreateTransaction(ctx, t.Request); err != nil {
 					log.Printf("[WARN] CreateTX failed after retries: %v", err)
 					mu.Lock()
 					retryTasks = append(retryTasks, t)
@@ -507,7 +714,7 @@ func (s *ProductService) ProcessBulkProductTransactionAsync(ctx context.Context,
 
 				time.Sleep(fetchDelay) // before FetchTX
 
-				txs, err := utils.FetchDTOneTransactionByExternalID(ctx, t.ExternalID)
+				txs, err := s.dtOne.FetchTransactionByExternalID(ctx, t.ExternalID)
 				if err != nil || len(txs) == 0 {
 					log.Printf("[WARN] [Worker %d] FetchTX failed for %s: %v", workerID, t.ExternalID, err)
 					mu.Lock()
@@ -519,12 +726,17 @@ func (s *ProductService) ProcessBulkProductTransactionAsync(ctx context.Context,
 
 				for _, tx := range txs {
 					tx.UpdatedAt = time.Now()
-					if err := s.productTransactionRepo.UpdateProductTransaction(ctx, tx.ExternalID, tx); err != nil {
+					applied, err := s.productTransactionRepo.UpdateProductTransaction(ctx, tx.ExternalID, tx)
+					if err != nil {
 						log.Printf("[ERROR] [Worker %d] UpdateTX failed for %s: %v", workerID, tx.ExternalID, err)
 						continue
 					}
 					log.Printf("[INFO] [Worker %d] TX fetched - ExternalID: %s, ProductID: %d, Pin: %s, Serial: %s",
 						workerID, tx.ExternalID, tx.Product.UniqueId, tx.Pin.Code, tx.Pin.Serial)
+					// A pin still pending at DT One arrives later through its callback.
+					if !applied || tx.Pin.Code == "" {
+						continue
+					}
 
 					resultChan <- model.ProductPinItem{
 						ExternalID: tx.ExternalID,
@@ -545,11 +757,17 @@ func (s *ProductService) ProcessBulkProductTransactionAsync(ctx context.Context,
 
 	go func() {
 		log.Printf("[DEBUG] Dispatching tasks...")
-		for _, item := range req.LineItems {
+		for index, item := range req.LineItems {
+			txReq, err := s.buildTransactionRequest(ctx, "", item.ProductID, req.MobileNumber, item.TransactionAmount, item.PartyFields)
+			if err != nil {
+				log.Printf("[ERROR] Skipping ProductID %d: %v", item.ProductID, err)
+				continue
+			}
 			for i := 0; i < item.Quantity; i++ {
 				externalID := fmt.Sprintf("TX-%s-%d", uuid.New().String()[:8], item.ProductID)
 				log.Printf("[DEBUG] Queuing task for ProductID: %d, ExternalID: %s", item.ProductID, externalID)
-				taskChan <- task{LineItem: item, ExternalID: externalID}
+				txReq.ExternalID = externalID
+				taskChan <- task{LineItem: item, Index: index, ExternalID: externalID, Request: txReq}
 			}
 		}
 		close(taskChan)
@@ -570,7 +788,7 @@ func (s *ProductService) ProcessBulkProductTransactionAsync(ctx context.Context,
 		log.Printf("[RETRY] Retrying CreateTX for ExternalID: %s", t.ExternalID)
 		time.Sleep(createDelay) // before CreateTX
 
-		if err := utils.CreateDTOneTransaction(ctx, t.ExternalID, t.LineItem.ProductID, req.MobileNumber); err != nil {
+		if _, err := s.dtOne.CreateTransaction(ctx, t.Request); err != nil {
 			log.Printf("[ERROR] Final CreateTX failed for %s: %v", t.ExternalID, err)
 			continue
 		}
@@ -583,7 +801,7 @@ func (s *ProductService) ProcessBulkProductTransactionAsync(ctx context.Context,
 
 		time.Sleep(fetchDelay) // before FetchTX
 
-		txs, err := utils.FetchDTOneTransactionByExternalID(ctx, t.ExternalID)
+		txs, err := s.dtOne.FetchTransactionByExternalID(ctx, t.ExternalID)
 		if err != nil || len(txs) == 0 {
 			log.Printf("[ERROR] Final FetchTX failed for %s: %v", t.ExternalID, err)
 			continue
@@ -591,10 +809,14 @@ func (s *ProductService) ProcessBulkProductTransactionAsync(ctx context.Context,
 
 		for _, tx := range txs {
 			tx.UpdatedAt = time.Now()
-			if err := s.productTransactionRepo.UpdateProductTransaction(ctx, tx.ExternalID, tx); err != nil {
+			applied, err := s.productTransactionRepo.UpdateProductTransaction(ctx, tx.ExternalID, tx)
+			if err != nil {
 				log.Printf("[ERROR] UpdateTX (after retry) failed for %s: %v", tx.ExternalID, err)
 				continue
 			}
+			if !applied || tx.Pin.Code == "" {
+				continue
+			}
 
 			pinItems = append(pinItems, model.ProductPinItem{
 				ExternalID: tx.ExternalID,
@@ -622,42 +844,47 @@ func (s *ProductService) ProcessBulkProductTransactionAsync(ctx context.Context,
 		if err := s.productOrderRepo.SaveProductPinsDump(ctx, dump); err != nil {
 			log.Printf("[ERROR] Error saving pin dump for OrderID %s: %v", orderId, err)
 		}
-	}
 
-	// Final update to ProductOrder
-	finalPins, err := s.productOrderRepo.GetPinsByOrderID(ctx, orderId)
-	if err != nil {
-		log.Printf("[ERROR] Error fetching dumped pins for OrderID %s: %v", orderId, err)
-		return err
+		// Final update to ProductOrder
+		finalPins, err := s.productOrderRepo.GetPinsByOrderID(ctx, orderId)
+		if err != nil {
+			log.Printf("[ERROR] Error fetching dumped pins for OrderID %s: %v", orderId, err)
+			return err
+		}
+
+		// Merge rather than overwrite: DT One callbacks may already have added pins.
+		if err := s.productOrderRepo.AddProductPins(ctx, orderId, finalPins); err != nil {
+			log.Printf("[ERROR] Error updating ProductOrder with final pins for OrderID %s: %v", orderId, err)
+			return err
+		}
 	}
 
 	log.Printf("Total execution time for OrderID %s: %v", orderId, time.Since(startTime))
 
-	if err := s.productOrderRepo.UpdateProductOrderWithPins(ctx, orderId, finalPins); err != nil {
-		log.Printf("[ERROR] Error updating ProductOrder with final pins for OrderID %s: %v", orderId, err)
-		return err
+	// Count the order's pins, including those DT One callbacks added.
+	fulfilled := 0
+	order, err := s.productOrderRepo.GetProductOrder(ctx, orderId)
+	if err != nil {
+		log.Printf("[ERROR] Error fetching ProductOrder %s: %v", orderId, err)
+	}
+	for _, pin := range order.ProductPins {
+		if pin.Pin.Code != "" {
+			fulfilled++
+		}
 	}
 
-	log.Printf("[SUCCESS] OrderID %s processed with %d total pins", orderId, len(finalPins))
-	return nil
-}
+	log.Printf("[SUCCESS] OrderID %s processed with %d total pins", orderId, fulfilled)
 
-func retryOn429(fn func() error) error {
-	backoff := time.Second
-	for i := 0; i < 5; i++ {
-		err := fn()
-		if err == nil {
-			return nil
-		}
-		if strings.Contains(err.Error(), "429") {
-			log.Printf("[RETRY] 429 received. Backing off for %v...", backoff)
-			time.Sleep(backoff)
-			backoff *= 2 // exponential backoff
-			continue
-		}
-		return err
+	requested := 0
+	for _, item := range req.LineItems {
+		requested += item.Quantity
 	}
-	return fmt.Errorf("failed after retries")
+	s.webhooks.Emit(ctx, order.UserID, model.WebhookEventBulkOrderCompleted, map[string]interface{}{
+		"order_id":  orderId,
+		"requested": requested,
+		"fulfilled": fulfilled,
+	})
+	return nil
 }
 
 // func (s *ProductService) ProcessBulkProductTransactionAsync(ctx context.Context, req dto.BulkTransactionRequest, orderId string) error {
@@ -740,7 +967,7 @@ func retryOn429(fn func() error) error {
 
 // 				for _, tx := range txs {
 // 					tx.UpdatedAt = time.Now()
-// 					if err := s.productTransactionRepo.UpdateProductTransaction(ctx, tx.ExternalID, tx); err != nil {
+// 					if _, err := s.productTransactionRepo.UpdateProductTransaction(ctx, tx.ExternalID, tx); err != nil {
 // 						errorChan <- fmt.Errorf("UpdateTX failed for %s: %w", tx.ExternalID, err)
 // 						continue
 // 					}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+
+	"github.com/aakritigkmit/payment-gateway/internal/constants"
+	"github.com/aakritigkmit/payment-gateway/internal/dto"
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"github.com/aakritigkmit/payment-gateway/internal/utils"
+)
+
+// GetBulkOrderStatus reports the progress of a bulk order from the transactions it has
+// placed. Pins are included only when the caller is the user who placed the order.
+func (s *ProductService) GetBulkOrderStatus(ctx context.Context, orderID string) (dto.BulkOrderStatusResponse, error) {
+	order, err := s.productOrderRepo.GetProductOrder(ctx, orderID)
+	if err != nil {
+		return dto.BulkOrderStatusResponse{}, err
+	}
+	txs, err := s.productTransactionRepo.FindByOrderID(ctx, orderID)
+	if err != nil {
+		return dto.BulkOrderStatusResponse{}, err
+	}
+
+	userID := utils.UserIDFromContext(ctx)
+	showPins := userID != "" && userID == order.UserID
+	finished := order.FinishedAt != nil
+
+	lines := make([]dto.BulkOrderLineItemStatus, 0, len(order.LineItems))
+	for _, item := range order.LineItems {
+		lines = append(lines, dto.BulkOrderLineItemStatus{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Counts:    dto.BulkOrderCounts{Requested: item.Quantity},
+			Items:     []dto.BulkOrderItemStatus{},
+		})
+	}
+
+	for _, tx := range txs {
+		i := lineItemFor(lines, tx)
+		if i < 0 {
+			// Orders placed before line items were recorded.
+			lines = append(lines, dto.BulkOrderLineItemStatus{ProductID: tx.Product.UniqueId, Items: []dto.BulkOrderItemStatus{}})
+			i = len(lines) - 1
+		}
+
+		item := dto.BulkOrderItemStatus{
+			ExternalID:    tx.ExternalID,
+			TransactionID: tx.ID,
+			State:         bulkItemState(tx, finished),
+			DTOneStatus:   tx.Status,
+			UpdatedAt:     tx.UpdatedAt,
+		}
+		if showPins && tx.Pin.Code != "" {
+			pin := tx.Pin
+			item.Pin = &pin
+		}
+		lines[i].Items = append(lines[i].Items, item)
+		countBulkItem(&lines[i].Counts, item.State, 1)
+	}
+
+	resp := dto.BulkOrderStatusResponse{
+		OrderID:    order.OrderID,
+		LineItems:  lines,
+		CreatedAt:  order.CreatedAt,
+		UpdatedAt:  order.UpdatedAt,
+		FinishedAt: order.FinishedAt,
+	}
+	for i := range lines {
+		counts := &lines[i].Counts
+		if counts.Requested < len(lines[i].Items) {
+			counts.Requested = len(lines[i].Items)
+		}
+		// Units never handed to DT One are still queued, or failed if processing stopped.
+		if remaining := counts.Requested - len(lines[i].Items); remaining > 0 {
+			if finished {
+				countBulkItem(counts, dto.BulkItemFailed, remaining)
+			} else {
+				countBulkItem(counts, dto.BulkItemQueued, remaining)
+			}
+		}
+
+		resp.Counts.Requested += counts.Requested
+		resp.Counts.Queued += counts.Queued
+		resp.Counts.Created += counts.Created
+		resp.Counts.Fulfilled += counts.Fulfilled
+		resp.Counts.Failed += counts.Failed
+	}
+	resp.State = bulkOrderState(resp.Counts, finished)
+	return resp, nil
+}
+
+// lineItemFor returns the index of the line item tx was placed for, or -1.
+func lineItemFor(lines []dto.BulkOrderLineItemStatus, tx model.ProductTransaction) int {
+	if tx.LineItemIndex != nil && *tx.LineItemIndex >= 0 && *tx.LineItemIndex < len(lines) {
+		return *tx.LineItemIndex
+	}
+	for i, line := range lines {
+		if tx.Product.UniqueId != 0 && line.ProductID == tx.Product.UniqueId {
+			return i
+		}
+	}
+	return -1
+}
+
+func bulkItemState(tx model.ProductTransaction, finished bool) string {
+	switch tx.Status.Class.ID {
+	case constants.TransactionStatusClasses.Completed:
+		return dto.BulkItemFulfilled
+	case constants.TransactionStatusClasses.Created,
+		constants.TransactionStatusClasses.Confirmed,
+		constants.TransactionStatusClasses.Submitted:
+		return dto.BulkItemCreated
+	case constants.TransactionStatusClasses.Rejected,
+		constants.TransactionStatusClasses.Cancelled,
+		constants.TransactionStatusClasses.Declined,
+		constants.TransactionStatusClasses.Reversed:
+		return dto.BulkItemFailed
+	}
+	// Saved but not yet accepted by DT One.
+	if finished {
+		return dto.BulkItemFailed
+	}
+	return dto.BulkItemQueued
+}
+
+func countBulkItem(counts *dto.BulkOrderCounts, state string, n int) {
+	switch state {
+	case dto.BulkItemQueued:
+		counts.Queued += n
+	case dto.BulkItemCreated:
+		counts.Created += n
+	case dto.BulkItemFulfilled:
+		counts.Fulfilled += n
+	case dto.BulkItemFailed:
+		counts.Failed += n
+	}
+}
+
+func bulkOrderState(counts dto.BulkOrderCounts, finished bool) string {
+	switch {
+	case !finished || counts.Queued > 0 || counts.Created > 0:
+		return dto.BulkOrderProcessing
+	case counts.Fulfilled == counts.Requested:
+		return dto.BulkOrderCompleted
+	case counts.Fulfilled > 0:
+		return dto.BulkOrderPartiallyCompleted
+	default:
+		return dto.BulkOrderFailed
+	}
+}
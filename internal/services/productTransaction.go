@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/constants"
+	"github.com/aakritigkmit/payment-gateway/internal/dto"
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"github.com/aakritigkmit/payment-gateway/internal/repository"
+	"github.com/aakritigkmit/payment-gateway/internal/utils"
+)
+
+// ValidationError is returned when a purchase does not satisfy its product, before
+// anything is sent to DT One. It wraps ErrInvalidTransactionRequest.
+type ValidationError struct {
+	Fields []dto.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+	return fmt.Sprintf("%v: %s", ErrInvalidTransactionRequest, strings.Join(parts, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidTransactionRequest
+}
+
+// fieldFormats are the shapes DT One expects for well-known party fields. Fields not
+// listed only need to be non-empty.
+var fieldFormats = map[string]struct {
+	pattern *regexp.Regexp
+	message string
+}{
+	"mobile_number":    {regexp.MustCompile(`^\+?[0-9]{6,15}$`), "must be 6 to 15 digits with an optional leading +"},
+	"email":            {regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`), "must be an email address"},
+	"date_of_birth":    {regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`), "must be a date in YYYY-MM-DD format"},
+	"country_iso_code": {regexp.MustCompile(`^[A-Z]{3}$`), "must be an ISO 3166-1 alpha-3 code"},
+}
+
+// buildTransactionRequest turns a purchase into a DT One transaction body, checking it
+// against the product in the local catalog first. Ranged-value products need exactly one
+// of the source or destination amounts, which is sent in the product's currency on that
+// side; fixed-value products take neither. Every required party field group must be
+// present and well-formed. Failures are reported together as a *ValidationError.
+func (s *ProductService) buildTransactionRequest(ctx context.Context, externalID string, productID int, mobileNumber string, amount dto.TransactionAmount, party dto.PartyFields) (dto.DTOneTransactionRequest, error) {
+	req := dto.DTOneTransactionRequest{
+		ExternalID:  externalID,
+		ProductID:   productID,
+		AutoConfirm: true,
+		PartyFields: withMobileNumber(party, mobileNumber),
+	}
+
+	product, err := s.productRepo.GetProductByUniqueID(ctx, productID)
+	if err != nil {
+		return req, err
+	}
+	if product.Inactive {
+		return req, &ValidationError{Fields: []dto.FieldError{{Field: "product_id", Message: "product is no longer offered"}}}
+	}
+
+	fields, err := setTransactionAmount(&req, product, amount)
+	if err != nil {
+		return req, err
+	}
+	fields = append(fields, checkFieldGroups("credit_party_identifier", product.RequiredCreditPartyIdentifierFields, req.CreditPartyIdentifier)...)
+	fields = append(fields, checkFieldGroups("debit_party_identifier", product.RequiredDebitPartyIdentifierFields, req.DebitPartyIdentifier)...)
+	fields = append(fields, checkFieldGroups("sender", product.RequiredSenderFields, req.Sender)...)
+	fields = append(fields, checkFieldGroups("beneficiary", product.RequiredBeneficiaryFields, req.Beneficiary)...)
+	fields = append(fields, checkFieldGroups("statement_identifier", product.RequiredStatementIdentifierFields, req.StatementIdentifier)...)
+
+	if len(fields) > 0 {
+		return req, &ValidationError{Fields: fields}
+	}
+	return req, nil
+}
+
+// setTransactionAmount sets the calculation mode and amount of a ranged-value purchase,
+// returning the field errors of the requested amount.
+func setTransactionAmount(req *dto.DTOneTransactionRequest, product model.Product, amount dto.TransactionAmount) ([]dto.FieldError, error) {
+	if !product.IsRanged() {
+		var fields []dto.FieldError
+		if amount.SourceAmount != nil {
+			fields = append(fields, dto.FieldError{Field: "source_amount", Message: "not accepted for fixed-value products"})
+		}
+		if amount.DestinationAmount != nil {
+			fields = append(fields, dto.FieldError{Field: "destination_amount", Message: "not accepted for fixed-value products"})
+		}
+		return fields, nil
+	}
+
+	var (
+		field string
+		value float64
+		side  model.ProductAmount
+	)
+	switch {
+	case amount.SourceAmount != nil && amount.DestinationAmount != nil:
+		return []dto.FieldError{{Field: "destination_amount", Message: "give either source_amount or destination_amount, not both"}}, nil
+	case amount.SourceAmount != nil:
+		field, value, side = "source_amount", *amount.SourceAmount, product.Source
+		req.CalculationMode = constants.CalculationModes.SourceAmount
+		req.Source = &dto.DTOneAmount{Amount: value, Unit: side.Unit}
+	case amount.DestinationAmount != nil:
+		field, value, side = "destination_amount", *amount.DestinationAmount, product.Destination
+		req.CalculationMode = constants.CalculationModes.DestinationAmount
+		req.Destination = &dto.DTOneAmount{Amount: value, Unit: side.Unit}
+	default:
+		return []dto.FieldError{{Field: "destination_amount", Message: "source_amount or destination_amount is required for ranged-value products"}}, nil
+	}
+
+	if side.Unit == "" {
+		return nil, fmt.Errorf("product %d has no %s currency; resync the catalog", product.UniqueId, strings.TrimSuffix(field, "_amount"))
+	}
+	if value <= 0 {
+		return []dto.FieldError{{Field: field, Message: "must be positive"}}, nil
+	}
+	if (side.MinAmount != nil && value < *side.MinAmount) || (side.MaxAmount != nil && value > *side.MaxAmount) {
+		return []dto.FieldError{{Field: field, Message: fmt.Sprintf("must be between %s and %s %s", formatBound(side.MinAmount), formatBound(side.MaxAmount), side.Unit)}}, nil
+	}
+	return nil, nil
+}
+
+func formatBound(v *float64) string {
+	if v == nil {
+		return "any"
+	}
+	return fmt.Sprintf("%g", *v)
+}
+
+// checkFieldGroups checks values against a product's required field groups. DT One lists
+// alternatives: the values must complete at least one group. When none is complete the
+// errors point at the missing fields of the closest group.
+func checkFieldGroups(prefix string, groups [][]string, values map[string]string) []dto.FieldError {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var fields []dto.FieldError
+	for _, key := range keys {
+		value := values[key]
+		if format, ok := fieldFormats[key]; ok && strings.TrimSpace(value) != "" && !format.pattern.MatchString(value) {
+			fields = append(fields, dto.FieldError{Field: prefix + "." + key, Message: format.message})
+		}
+	}
+
+	var closest []string
+	for _, group := range groups {
+		var missing []string
+		for _, key := range group {
+			if strings.TrimSpace(values[key]) == "" {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) == 0 {
+			return fields
+		}
+		if closest == nil || len(missing) < len(closest) {
+			closest = missing
+		}
+	}
+	for _, key := range closest {
+		fields = append(fields, dto.FieldError{Field: prefix + "." + key, Message: "is required"})
+	}
+	return fields
+}
+
+// ConfirmTransaction confirms a transaction the caller created with auto_confirm false.
+// It must still be awaiting confirmation and within its confirmation window.
+func (s *ProductService) ConfirmTransaction(ctx context.Context, externalID string) (model.ProductTransaction, error) {
+	tx, err := s.awaitingConfirmation(ctx, externalID)
+	if err != nil {
+		return model.ProductTransaction{}, err
+	}
+	if !tx.ConfirmationExpirationDate.IsZero() && time.Now().After(tx.ConfirmationExpirationDate) {
+		return model.ProductTransaction{}, fmt.Errorf("%w: confirmation expired at %s", ErrInvalidTransactionState, tx.ConfirmationExpirationDate.Format(time.RFC3339))
+	}
+
+	confirmed, err := s.dtOne.ConfirmTransaction(ctx, tx.ID)
+	if err != nil {
+		return model.ProductTransaction{}, fmt.Errorf("failed to confirm transaction %s: %w", externalID, err)
+	}
+	saved, _, err := s.saveTransactionUpdate(ctx, confirmed)
+	return saved, err
+}
+
+// CancelTransaction cancels a transaction the caller created that is still awaiting
+// confirmation.
+func (s *ProductService) CancelTransaction(ctx context.Context, externalID string) (model.ProductTransaction, error) {
+	tx, err := s.awaitingConfirmation(ctx, externalID)
+	if err != nil {
+		return model.ProductTransaction{}, err
+	}
+
+	cancelled, err := s.dtOne.CancelTransaction(ctx, tx.ID)
+	if err != nil {
+		return model.ProductTransaction{}, fmt.Errorf("failed to cancel transaction %s: %w", externalID, err)
+	}
+	saved, _, err := s.saveTransactionUpdate(ctx, cancelled)
+	return saved, err
+}
+
+// SweepUnconfirmedTransactions cancels transactions still awaiting confirmation whose
+// confirmation expires within lead, so none is left to lapse at DT One. It returns how
+// many were cancelled.
+func (s *ProductService) SweepUnconfirmedTransactions(ctx context.Context, lead time.Duration, batchSize int64) (int, error) {
+	txs, err := s.productTransactionRepo.FindExpiringInStatusClass(ctx, constants.TransactionStatusClasses.Created, time.Now().Add(lead), batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	cancelled := 0
+	for _, tx := range txs {
+		if ctx.Err() != nil {
+			return cancelled, ctx.Err()
+		}
+		result, err := s.dtOne.CancelTransaction(ctx, tx.ID)
+		if err != nil {
+			log.Printf("[TransactionSweep] Cancelling %s failed: %v", tx.ExternalID, err)
+			// DT One may already have moved it on; pick up its current state.
+			s.refreshTransaction(ctx, tx.ExternalID)
+			continue
+		}
+		if _, _, err := s.saveTransactionUpdate(ctx, result); err != nil {
+			log.Printf("[TransactionSweep] %v", err)
+			continue
+		}
+		cancelled++
+	}
+	return cancelled, nil
+}
+
+func (s *ProductService) awaitingConfirmation(ctx context.Context, externalID string) (*model.ProductTransaction, error) {
+	tx, err := s.productTransactionRepo.FindProductTransactionByExternalID(ctx, externalID)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil || tx.CreatedBy != utils.UserIDFromContext(ctx) {
+		return nil, repository.ErrProductTransactionNotFound
+	}
+	if tx.Status.Class.ID != constants.TransactionStatusClasses.Created {
+		return nil, fmt.Errorf("%w: transaction is %s", ErrInvalidTransactionState, tx.Status.Message)
+	}
+	return tx, nil
+}
+
+// saveTransactionUpdate stores the state DT One returned for a transaction. The stored
+// product is kept when DT One's response omits it. It reports false when the stored
+// transaction had already moved past that state.
+func (s *ProductService) saveTransactionUpdate(ctx context.Context, tx model.ProductTransaction) (model.ProductTransaction, bool, error) {
+	stored, err := s.productTransactionRepo.FindProductTransactionByExternalID(ctx, tx.ExternalID)
+	if err != nil {
+		return model.ProductTransaction{}, false, err
+	}
+	if stored == nil {
+		return model.ProductTransaction{}, false, repository.ErrProductTransactionNotFound
+	}
+	if tx.Product.UniqueId == 0 {
+		tx.Product = stored.Product
+	}
+	applied, err := s.productTransactionRepo.UpdateProductTransaction(ctx, tx.ExternalID, tx)
+	if err != nil {
+		return model.ProductTransaction{}, false, fmt.Errorf("failed to save transaction %s: %w", tx.ExternalID, err)
+	}
+	tx.OrderID = stored.OrderID
+	tx.CreatedAt = stored.CreatedAt
+	return tx, applied, nil
+}
+
+// refreshTransaction stores DT One's current view of a transaction, logging failures.
+func (s *ProductService) refreshTransaction(ctx context.Context, externalID string) {
+	txs, err := s.dtOne.FetchTransactionByExternalID(ctx, externalID)
+	if err != nil || len(txs) == 0 {
+		log.Printf("[TransactionSweep] Refreshing %s failed: %v", externalID, err)
+		return
+	}
+	if _, _, err := s.saveTransactionUpdate(ctx, txs[0]); err != nil {
+		log.Printf("[TransactionSweep] %v", err)
+	}
+}
+
+// withMobileNumber fills credit_party_identifier.mobile_number from the request-level
+// mobile number unless the caller set it. The map is copied, since bulk line items
+// share it across units.
+func withMobileNumber(party dto.PartyFields, mobileNumber string) dto.PartyFields {
+	credit := make(map[string]string, len(party.CreditPartyIdentifier)+1)
+	for k, v := range party.CreditPartyIdentifier {
+		credit[k] = v
+	}
+	if credit["mobile_number"] == "" && mobileNumber != "" {
+		credit["mobile_number"] = mobileNumber
+	}
+	if len(credit) > 0 {
+		party.CreditPartyIdentifier = credit
+	} else {
+		party.CreditPartyIdentifier = nil
+	}
+	return party
+}
+
+// validateBulkLineItems checks every line item of a bulk order up front, so a bad order
+// is rejected as a whole with field paths under lineItems[i].
+func (s *ProductService) validateBulkLineItems(ctx context.Context, req dto.BulkTransactionRequest) error {
+	var fields []dto.FieldError
+	if len(req.LineItems) == 0 {
+		fields = append(fields, dto.FieldError{Field: "lineItems", Message: "at least one line item is required"})
+	}
+	for i, item := range req.LineItems {
+		prefix := fmt.Sprintf("lineItems[%d].", i)
+		if item.Quantity < 1 {
+			fields = append(fields, dto.FieldError{Field: prefix + "quantity", Message: "must be at least 1"})
+		}
+
+		_, err := s.buildTransactionRequest(ctx, "", item.ProductID, req.MobileNumber, item.TransactionAmount, item.PartyFields)
+		var invalid *ValidationError
+		switch {
+		case err == nil:
+		case errors.As(err, &invalid):
+			for _, f := range invalid.Fields {
+				fields = append(fields, dto.FieldError{Field: prefix + f.Field, Message: f.Message})
+			}
+		case errors.Is(err, repository.ErrProductNotFound):
+			fields = append(fields, dto.FieldError{Field: prefix + "productId", Message: "product not found"})
+		default:
+			return err
+		}
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/config"
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"github.com/aakritigkmit/payment-gateway/internal/repository"
+)
+
+// OrderReconciler brings orders whose provider callback never arrived up to date
+// by polling the provider directly.
+type OrderReconciler struct {
+	orderService *OrderService
+	orderRepo    *repository.OrderRepo
+	runRepo      *repository.ReconciliationRepo
+	staleAfter   time.Duration
+	concurrency  int
+	batchSize    int
+}
+
+func NewOrderReconciler(orderService *OrderService, orderRepo *repository.OrderRepo, runRepo *repository.ReconciliationRepo, cfg *config.Config) *OrderReconciler {
+	concurrency := cfg.ReconcileConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &OrderReconciler{
+		orderService: orderService,
+		orderRepo:    orderRepo,
+		runRepo:      runRepo,
+		staleAfter:   cfg.ReconcileStaleAfter,
+		concurrency:  concurrency,
+		batchSize:    cfg.ReconcileBatchSize,
+	}
+}
+
+// Run reconciles one batch of stale orders and returns a summary of what changed.
+func (r *OrderReconciler) Run(ctx context.Context) (model.ReconciliationRun, error) {
+	run := model.ReconciliationRun{
+		StartedAt: time.Now(),
+		Cutoff:    time.Now().Add(-r.staleAfter),
+		Changes:   []model.ReconciliationChange{},
+		Errors:    []model.ReconciliationChange{},
+	}
+
+	orders, err := r.orderRepo.FindStaleOrders(ctx, run.Cutoff, int64(r.batchSize))
+	if err != nil {
+		return run, err
+	}
+	log.Printf("[Reconcile] Found %d stale orders older than %v", len(orders), r.staleAfter)
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		sem = make(chan struct{}, r.concurrency)
+	)
+
+	for _, order := range orders {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return run, ctx.Err()
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(orderID string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			from, to, err := r.orderService.SyncOrderWithProvider(ctx, orderID, model.ActorReconciler)
+			// Move the order to the back of the queue whatever the outcome.
+			if markErr := r.orderRepo.MarkReconciled(ctx, orderID); markErr != nil {
+				log.Printf("[Reconcile] Order %s: %v", orderID, markErr)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			run.Checked++
+			switch {
+			case err != nil:
+				run.Failed++
+				run.Errors = append(run.Errors, model.ReconciliationChange{OrderID: orderID, From: from, Error: err.Error()})
+			case from != to:
+				run.Updated++
+				run.Changes = append(run.Changes, model.ReconciliationChange{OrderID: orderID, From: from, To: to})
+			default:
+				run.Unchanged++
+			}
+		}(order.TransactionReferenceId)
+	}
+	wg.Wait()
+
+	run.FinishedAt = time.Now()
+	log.Printf("[Reconcile] Checked: %d, Updated: %d, Unchanged: %d, Failed: %d in %v",
+		run.Checked, run.Updated, run.Unchanged, run.Failed, run.FinishedAt.Sub(run.StartedAt))
+	for _, change := range run.Changes {
+		log.Printf("[Reconcile] Order %s: %s -> %s", change.OrderID, change.From, change.To)
+	}
+
+	if err := r.runRepo.SaveRun(ctx, run); err != nil {
+		log.Printf("[Reconcile] %v", err)
+	}
+
+	return run, nil
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/dto"
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"github.com/aakritigkmit/payment-gateway/internal/repository"
+	"github.com/aakritigkmit/payment-gateway/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// mapProviderRefundStatus translates a provider refund status onto the ledger lifecycle.
+func mapProviderRefundStatus(status string) model.RefundStatus {
+	switch strings.ToUpper(status) {
+	case "PROCESSED", "SUCCESS", "REFUNDED":
+		return model.RefundStatusSucceeded
+	case "FAILED", "CANCELLED", "REJECTED":
+		return model.RefundStatusFailed
+	default:
+		return model.RefundStatusPending
+	}
+}
+
+// applyRefundStatus moves a ledger entry according to the provider's refund status.
+// A succeeded refund is settled against the order, a failed one releases its
+// reservation, and anything else leaves the entry pending.
+func (s *OrderService) applyRefundStatus(ctx context.Context, entry model.RefundEntry, providerRefundID, providerStatus string, from []model.RefundStatus) error {
+	fields := bson.M{"provider_status": providerStatus}
+	entry.ProviderStatus = providerStatus
+	if entry.NeedsReview {
+		fields["needs_review"] = false
+		entry.NeedsReview = false
+	}
+	if providerRefundID != "" {
+		fields["provider_refund_id"] = providerRefundID
+		entry.ProviderRefundID = providerRefundID
+	}
+
+	switch mapProviderRefundStatus(providerStatus) {
+	case model.RefundStatusSucceeded:
+		moved, err := s.refundRepo.TransitionEntry(ctx, entry.ID, from, model.RefundStatusSucceeded, fields)
+		if err != nil || !moved {
+			return err
+		}
+		entry.Status = model.RefundStatusSucceeded
+		if err := s.settleRefund(ctx, entry); err != nil {
+			return err
+		}
+		s.emitRefundEvent(ctx, model.WebhookEventRefundSucceeded, entry)
+		return nil
+
+	case model.RefundStatusFailed:
+		fields["failure_reason"] = "provider status " + providerStatus
+		moved, err := s.refundRepo.TransitionEntry(ctx, entry.ID, from, model.RefundStatusFailed, fields)
+		if err != nil || !moved {
+			return err
+		}
+		entry.Status = model.RefundStatusFailed
+		entry.FailureReason = "provider status " + providerStatus
+		if err := s.repo.ReleaseRefund(ctx, entry.OrderID, entry.Amount); err != nil {
+			return err
+		}
+		s.emitRefundEvent(ctx, model.WebhookEventRefundFailed, entry)
+		return nil
+
+	default:
+		_, err := s.refundRepo.TransitionEntry(ctx, entry.ID, from, model.RefundStatusPending, fields)
+		return err
+	}
+}
+
+// settleRefund adds a succeeded refund to the order's refunded total and moves the
+// order to refunded or partially_refunded.
+func (s *OrderService) settleRefund(ctx context.Context, entry model.RefundEntry) error {
+	order, err := s.repo.SettleRefund(ctx, entry.OrderID, entry.Amount)
+	if err != nil {
+		return err
+	}
+
+	// Orders without a recorded capture were captured in full, as in ReserveRefund.
+	captured := order.CapturedAmount
+	if captured <= 0 {
+		captured = order.Amount
+	}
+
+	nextStatus := model.OrderStatusPartiallyRefunded
+	if order.RefundedAmount >= captured {
+		nextStatus = model.OrderStatusRefunded
+	}
+
+	if _, err := s.transitionStatus(ctx, entry.OrderID, nextStatus, entry.RequestedBy, "refund "+entry.MerchantRefundReference); err != nil {
+		log.Printf("[Refund] Order %s status not updated after refund %s: %v", entry.OrderID, entry.MerchantRefundReference, err)
+	}
+	return nil
+}
+
+// markRefundEntryFailed records a refund that never reached the provider, releasing
+// its reservation when one was taken.
+func (s *OrderService) markRefundEntryFailed(ctx context.Context, entry model.RefundEntry, reason string, reserved bool) {
+	from := []model.RefundStatus{model.RefundStatusRequested}
+	moved, err := s.refundRepo.TransitionEntry(ctx, entry.ID, from, model.RefundStatusFailed, bson.M{"failure_reason": reason})
+	if err != nil {
+		log.Printf("[Refund] Failed to mark refund %s failed: %v", entry.MerchantRefundReference, err)
+		return
+	}
+	if moved && reserved {
+		if err := s.repo.ReleaseRefund(ctx, entry.OrderID, entry.Amount); err != nil {
+			log.Printf("[Refund] Failed to release refund %s: %v", entry.MerchantRefundReference, err)
+		}
+	}
+}
+
+// unlistedRefundReviewAfter is how long after submission a requested refund the provider
+// does not list is flagged for review. Its outcome is unknown, e.g. the submission timed
+// out, so it is never failed automatically: that could release funds the provider is
+// still refunding.
+const unlistedRefundReviewAfter = time.Hour
+
+// RefreshRefund looks an open refund up on its parent order at the provider and
+// applies the status found there. It returns the entry's status afterwards; a refund
+// the provider does not list yet keeps its current status, and is flagged for review
+// if it is still requested unlistedRefundReviewAfter after submission.
+func (s *OrderService) RefreshRefund(ctx context.Context, entry model.RefundEntry) (model.RefundStatus, error) {
+	provider, err := s.providerForOrder(ctx, entry.OrderID)
+	if err != nil {
+		return entry.Status, err
+	}
+
+	data, err := provider.GetOrder(ctx, entry.OrderID)
+	if err != nil {
+		return entry.Status, fmt.Errorf("failed to fetch order %s from %s: %w", entry.OrderID, provider.Name(), err)
+	}
+
+	refund, ok := findProviderRefund(data.Refunds, entry)
+	if !ok {
+		if entry.Status == model.RefundStatusRequested && time.Since(entry.CreatedAt) >= unlistedRefundReviewAfter {
+			flagged, err := s.refundRepo.FlagForReview(ctx, entry.ID)
+			if err != nil {
+				return entry.Status, err
+			}
+			if flagged {
+				log.Printf("[Refund] Refund %s on order %s is not listed by %s %v after submission; it needs review",
+					entry.MerchantRefundReference, entry.OrderID, provider.Name(), time.Since(entry.CreatedAt).Round(time.Minute))
+			}
+		}
+		return entry.Status, nil
+	}
+
+	if err := s.applyRefundStatus(ctx, entry, refund.OrderID, refund.Status, []model.RefundStatus{entry.Status}); err != nil {
+		return entry.Status, err
+	}
+
+	status := mapProviderRefundStatus(refund.Status)
+	if status.IsTerminal() {
+		// Keep the refund snapshot read by GetOrderDetails in step with the ledger.
+		order, err := s.repo.GetOrderByTransactionReferenceId(ctx, entry.OrderID)
+		if err != nil {
+			return status, err
+		}
+		if err := s.repo.SaveRefund(ctx, refundSnapshot(data, order.ID)); err != nil {
+			return status, fmt.Errorf("failed to save refund: %w", err)
+		}
+	}
+	return status, nil
+}
+
+// FailRefund lets a support user fail a requested refund the provider does not list,
+// releasing its reservation. The provider is checked once more first; a refund it now
+// lists takes the provider's status instead and ErrInvalidOrderState is returned.
+func (s *OrderService) FailRefund(ctx context.Context, orderID, refundID, reason string) (model.RefundEntry, error) {
+	if utils.UserRoleFromContext(ctx) != model.UserRoleSupport {
+		return model.RefundEntry{}, ErrSupportOnly
+	}
+	order, err := s.findOrder(ctx, orderID)
+	if err != nil {
+		return model.RefundEntry{}, err
+	}
+	id, err := primitive.ObjectIDFromHex(refundID)
+	if err != nil {
+		return model.RefundEntry{}, repository.ErrRefundEntryNotFound
+	}
+	entry, err := s.refundRepo.GetEntry(ctx, id)
+	if err != nil {
+		return model.RefundEntry{}, err
+	}
+	if entry.OrderID != order.TransactionReferenceId {
+		return model.RefundEntry{}, repository.ErrRefundEntryNotFound
+	}
+	if entry.Status != model.RefundStatusRequested {
+		return model.RefundEntry{}, fmt.Errorf("%w: refund is %s", ErrInvalidOrderState, entry.Status)
+	}
+
+	status, err := s.RefreshRefund(ctx, entry)
+	if err != nil {
+		return model.RefundEntry{}, err
+	}
+	if status != model.RefundStatusRequested {
+		return model.RefundEntry{}, fmt.Errorf("%w: provider lists the refund as %s", ErrInvalidOrderState, status)
+	}
+
+	if reason == "" {
+		reason = "failed by support: provider has no record of the refund"
+	}
+	if _, err := s.failUnlistedRefund(ctx, entry, reason); err != nil {
+		return model.RefundEntry{}, err
+	}
+	return s.refundRepo.GetEntry(ctx, id)
+}
+
+// failUnlistedRefund fails a requested refund the provider has no record of and
+// releases its reservation.
+func (s *OrderService) failUnlistedRefund(ctx context.Context, entry model.RefundEntry, reason string) (model.RefundStatus, error) {
+	from := []model.RefundStatus{model.RefundStatusRequested}
+	moved, err := s.refundRepo.TransitionEntry(ctx, entry.ID, from, model.RefundStatusFailed, bson.M{"failure_reason": reason, "needs_review": false})
+	if err != nil || !moved {
+		return entry.Status, err
+	}
+
+	entry.Status = model.RefundStatusFailed
+	entry.FailureReason = reason
+	if err := s.repo.ReleaseRefund(ctx, entry.OrderID, entry.Amount); err != nil {
+		return entry.Status, err
+	}
+	log.Printf("[Refund] Refund %s on order %s failed: %s", entry.MerchantRefundReference, entry.OrderID, reason)
+	s.emitRefundEvent(ctx, model.WebhookEventRefundFailed, entry)
+	return entry.Status, nil
+}
+
+// emitRefundEvent sends a refund event to the endpoints of the user who placed the order.
+func (s *OrderService) emitRefundEvent(ctx context.Context, eventType string, entry model.RefundEntry) {
+	order, err := s.repo.GetOrderByTransactionReferenceId(ctx, entry.OrderID)
+	if err != nil {
+		log.Printf("[Refund] Not sending %s for refund %s: %v", eventType, entry.MerchantRefundReference, err)
+		return
+	}
+	s.webhooks.Emit(ctx, order.CreatedBy, eventType, entry)
+}
+
+// findProviderRefund matches a ledger entry to a provider refund by provider refund ID,
+// falling back to the merchant refund reference for entries that never got one.
+func findProviderRefund(refunds []model.Refund, entry model.RefundEntry) (model.Refund, bool) {
+	for _, refund := range refunds {
+		if entry.ProviderRefundID != "" && refund.OrderID == entry.ProviderRefundID {
+			return refund, true
+		}
+	}
+	for _, refund := range refunds {
+		if refund.MerchantOrderReference == entry.MerchantRefundReference {
+			return refund, true
+		}
+	}
+	return model.Refund{}, false
+}
+
+// GetOrderRefunds returns an order's refund ledger together with its refund totals.
+// id may be the provider order ID or the order's Mongo ID.
+func (s *OrderService) GetOrderRefunds(ctx context.Context, id string) (dto.OrderRefundsResponse, error) {
+	order, err := s.findOrder(ctx, id)
+	if err != nil {
+		return dto.OrderRefundsResponse{}, err
+	}
+
+	entries, err := s.refundRepo.GetEntriesByOrderID(ctx, order.TransactionReferenceId)
+	if err != nil {
+		return dto.OrderRefundsResponse{}, err
+	}
+
+	return dto.OrderRefundsResponse{
+		OrderID:             order.TransactionReferenceId,
+		Status:              model.NormalizeOrderStatus(string(order.Status)),
+		Currency:            order.Currency,
+		CapturedAmount:      order.CapturedAmount,
+		RefundedAmount:      order.RefundedAmount,
+		RefundPendingAmount: order.RefundPendingAmount,
+		Refunds:             entries,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/config"
+	"github.com/aakritigkmit/payment-gateway/internal/repository"
+)
+
+// requestedRefundGrace is how long a refund may stay "requested" before the tracker
+// assumes its submission was interrupted and looks it up at the provider.
+const requestedRefundGrace = 5 * time.Minute
+
+// RefundTracker follows refunds the provider has not settled yet until they succeed or
+// fail. It runs as a scheduled job, so only one replica checks refunds at a time.
+type RefundTracker struct {
+	orderService *OrderService
+	refundRepo   *repository.RefundRepo
+	batchSize    int
+}
+
+func NewRefundTracker(orderService *OrderService, refundRepo *repository.RefundRepo, cfg *config.Config) *RefundTracker {
+	return &RefundTracker{
+		orderService: orderService,
+		refundRepo:   refundRepo,
+		batchSize:    cfg.RefundPollBatchSize,
+	}
+}
+
+// Run checks one batch of open refunds and returns how many reached a final status.
+func (t *RefundTracker) Run(ctx context.Context) (int, error) {
+	entries, err := t.refundRepo.FindOpenEntries(ctx, time.Now().Add(-requestedRefundGrace), int64(t.batchSize))
+	if err != nil {
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	settled, failed := 0, 0
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return settled, ctx.Err()
+		}
+
+		if err := t.refundRepo.MarkChecked(ctx, entry.ID); err != nil {
+			log.Printf("[RefundTracker] %v", err)
+		}
+
+		status, err := t.orderService.RefreshRefund(ctx, entry)
+		if err != nil {
+			failed++
+			log.Printf("[RefundTracker] Refund %s on order %s: %v", entry.MerchantRefundReference, entry.OrderID, err)
+			continue
+		}
+		if status.IsTerminal() {
+			settled++
+			log.Printf("[RefundTracker] Refund %s on order %s: %s", entry.MerchantRefundReference, entry.OrderID, status)
+		}
+	}
+
+	log.Printf("[RefundTracker] Checked: %d, Final: %d, Failed: %d", len(entries), settled, failed)
+	return settled, nil
+}
@@ -10,15 +10,11 @@ import (
 	"github.com/xuri/excelize/v2"
 )
 
-type ProductSaveError struct {
-	ProductID int
-	ErrorMsg  string
-}
-
-type FetchPageError struct {
-	Page     int
-	ErrorMsg string
-}
+// The error types live in model so background job records can store them.
+type (
+	ProductSaveError = model.ProductSaveError
+	FetchPageError   = model.FetchPageError
+)
 
 func GenerateProductSyncReport(successCount, saveErrorCount, fetchErrorCount int, saveErrors []ProductSaveError, fetchErrors []FetchPageError) error {
 	f := excelize.NewFile()
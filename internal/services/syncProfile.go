@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/constants"
+	"github.com/aakritigkmit/payment-gateway/internal/dto"
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ResolveSyncProfiles returns the profiles with the given IDs, or every enabled profile
+// when ids is empty.
+func (s *ProductService) ResolveSyncProfiles(ctx context.Context, ids []string) ([]model.SyncProfile, error) {
+	if len(ids) == 0 {
+		return s.syncProfileRepo.FindEnabled(ctx)
+	}
+
+	objectIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		objectID, err := parseSyncProfileID(id)
+		if err != nil {
+			return nil, err
+		}
+		objectIDs = append(objectIDs, objectID)
+	}
+	return s.syncProfileRepo.FindByIDs(ctx, objectIDs)
+}
+
+func (s *ProductService) CreateSyncProfile(ctx context.Context, req dto.CreateSyncProfileRequest) (model.SyncProfile, error) {
+	profile := model.SyncProfile{
+		Name:           strings.TrimSpace(req.Name),
+		ServiceID:      req.ServiceID,
+		Type:           req.Type,
+		CountryISOCode: strings.ToUpper(req.CountryISOCode),
+		OperatorID:     req.OperatorID,
+		Enabled:        req.Enabled == nil || *req.Enabled,
+	}
+	if err := validateSyncProfile(profile); err != nil {
+		return model.SyncProfile{}, err
+	}
+
+	now := time.Now()
+	profile.CreatedAt = now
+	profile.UpdatedAt = now
+	return s.syncProfileRepo.Create(ctx, profile)
+}
+
+func (s *ProductService) ListSyncProfiles(ctx context.Context) ([]model.SyncProfile, error) {
+	return s.syncProfileRepo.List(ctx)
+}
+
+func (s *ProductService) GetSyncProfile(ctx context.Context, id string) (model.SyncProfile, error) {
+	objectID, err := parseSyncProfileID(id)
+	if err != nil {
+		return model.SyncProfile{}, err
+	}
+	return s.syncProfileRepo.Get(ctx, objectID)
+}
+
+func (s *ProductService) UpdateSyncProfile(ctx context.Context, id string, req dto.UpdateSyncProfileRequest) (model.SyncProfile, error) {
+	profile, err := s.GetSyncProfile(ctx, id)
+	if err != nil {
+		return model.SyncProfile{}, err
+	}
+
+	if req.Name != nil {
+		profile.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.ServiceID != nil {
+		profile.ServiceID = *req.ServiceID
+	}
+	if req.Type != nil {
+		profile.Type = *req.Type
+	}
+	if req.CountryISOCode != nil {
+		profile.CountryISOCode = strings.ToUpper(*req.CountryISOCode)
+	}
+	if req.OperatorID != nil {
+		profile.OperatorID = *req.OperatorID
+	}
+	if req.Enabled != nil {
+		profile.Enabled = *req.Enabled
+	}
+	if err := validateSyncProfile(profile); err != nil {
+		return model.SyncProfile{}, err
+	}
+
+	return s.syncProfileRepo.Update(ctx, profile.ID, bson.M{
+		"name":             profile.Name,
+		"service_id":       profile.ServiceID,
+		"type":             profile.Type,
+		"country_iso_code": profile.CountryISOCode,
+		"operator_id":      profile.OperatorID,
+		"enabled":          profile.Enabled,
+	})
+}
+
+func (s *ProductService) DeleteSyncProfile(ctx context.Context, id string) error {
+	objectID, err := parseSyncProfileID(id)
+	if err != nil {
+		return err
+	}
+	return s.syncProfileRepo.Delete(ctx, objectID)
+}
+
+// syncFilter is the DT One product filter for a profile.
+func syncFilter(profile model.SyncProfile) dto.ProductSyncRequest {
+	return dto.ProductSyncRequest{
+		ServiceID:      profile.ServiceID,
+		CountryISOCode: profile.CountryISOCode,
+		Type:           profile.Type,
+		OperatorID:     profile.OperatorID,
+	}
+}
+
+// syncScope matches the stored products a profile covers, for deactivating those a
+// sync no longer returns.
+func syncScope(profile model.SyncProfile) bson.M {
+	scope := bson.M{"service.id": profile.ServiceID}
+	if profile.Type != "" {
+		scope["type"] = profile.Type
+	}
+	if profile.CountryISOCode != "" {
+		scope["operator.country.iso_code"] = profile.CountryISOCode
+	}
+	if profile.OperatorID != 0 {
+		scope["operator.id"] = profile.OperatorID
+	}
+	return scope
+}
+
+func validateSyncProfile(profile model.SyncProfile) error {
+	if profile.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidSyncProfile)
+	}
+	if profile.ServiceID <= 0 {
+		return fmt.Errorf("%w: service_id must be a positive integer", ErrInvalidSyncProfile)
+	}
+	if profile.OperatorID < 0 {
+		return fmt.Errorf("%w: operator_id must not be negative", ErrInvalidSyncProfile)
+	}
+	if profile.Type != "" && !isProductType(profile.Type) {
+		return fmt.Errorf("%w: unknown product type %q", ErrInvalidSyncProfile, profile.Type)
+	}
+	if profile.CountryISOCode != "" && len(profile.CountryISOCode) != 3 {
+		return fmt.Errorf("%w: country_iso_code must be a 3-letter code", ErrInvalidSyncProfile)
+	}
+	return nil
+}
+
+func isProductType(value string) bool {
+	switch value {
+	case constants.ProductTypes.FixedValueRecharge, constants.ProductTypes.RangedValueRecharge,
+		constants.ProductTypes.FixedValuePinPurchase, constants.ProductTypes.RangedValuePinPurchase,
+		constants.ProductTypes.RangedValuePayment:
+		return true
+	}
+	return false
+}
+
+func parseSyncProfileID(id string) (primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("%w: invalid id %q", ErrInvalidSyncProfile, id)
+	}
+	return objectID, nil
+}
@@ -0,0 +1,465 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/config"
+	"github.com/aakritigkmit/payment-gateway/internal/dto"
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"github.com/aakritigkmit/payment-gateway/internal/repository"
+	"github.com/aakritigkmit/payment-gateway/internal/utils"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Headers sent with every webhook delivery. The signature header has the form
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">" keyed by the endpoint secret.
+const (
+	WebhookSignatureHeader = "X-Gateway-Signature"
+	WebhookEventHeader     = "X-Gateway-Event"
+	WebhookDeliveryHeader  = "X-Gateway-Delivery"
+)
+
+// webhookClaimLease is how long a claimed delivery is hidden from other dispatchers.
+const webhookClaimLease = 2 * time.Minute
+
+// WebhookService records gateway events for merchant endpoints and delivers them
+// with retries.
+type WebhookService struct {
+	repo        *repository.WebhookRepo
+	client      *http.Client
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+func NewWebhookService(repo *repository.WebhookRepo, cfg *config.Config) *WebhookService {
+	maxAttempts := cfg.WebhookMaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &WebhookService{
+		repo:        repo,
+		client:      newWebhookClient(cfg.WebhookTimeout),
+		maxAttempts: maxAttempts,
+		baseDelay:   cfg.WebhookRetryBaseDelay,
+		maxDelay:    cfg.WebhookRetryMaxDelay,
+	}
+}
+
+// Emit queues an event for every active endpoint of owner, the user the event's
+// order, refund or bulk order belongs to, that is subscribed to eventType. Events
+// without an owner are not sent anywhere. Failures are logged rather than returned so
+// that callers' state changes are never undone by a webhook problem.
+func (s *WebhookService) Emit(ctx context.Context, owner, eventType string, data interface{}) {
+	if owner == "" {
+		log.Printf("[Webhook] Not sending %s event: it has no owner", eventType)
+		return
+	}
+
+	event := model.WebhookEvent{
+		ID:        "evt_" + uuid.New().String(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("[Webhook] Failed to marshal %s event: %v", eventType, err)
+		return
+	}
+
+	endpoints, err := s.repo.FindActiveEndpoints(ctx, owner)
+	if err != nil {
+		log.Printf("[Webhook] Failed to load endpoints for %s: %v", eventType, err)
+		return
+	}
+
+	now := time.Now()
+	deliveries := make([]model.WebhookDelivery, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		if !endpoint.Subscribes(eventType) {
+			continue
+		}
+		deliveries = append(deliveries, model.WebhookDelivery{
+			EndpointID:    endpoint.ID,
+			CreatedBy:     owner,
+			EventID:       event.ID,
+			EventType:     eventType,
+			Payload:       string(payload),
+			Status:        model.WebhookDeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+	}
+
+	if err := s.repo.CreateDeliveries(ctx, deliveries); err != nil {
+		log.Printf("[Webhook] Failed to queue %s event %s: %v", eventType, event.ID, err)
+		return
+	}
+	if len(deliveries) > 0 {
+		log.Printf("[Webhook] Queued %s event %s for %d endpoints", eventType, event.ID, len(deliveries))
+	}
+}
+
+// Start delivers due webhooks every interval until ctx is cancelled.
+func (s *WebhookService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[Webhook] Dispatcher stopped.")
+			return
+		case <-ticker.C:
+			s.DispatchDue(ctx)
+		}
+	}
+}
+
+// DispatchDue attempts every delivery that is currently due and returns how many were tried.
+func (s *WebhookService) DispatchDue(ctx context.Context) int {
+	attempted := 0
+	for ctx.Err() == nil {
+		delivery, err := s.repo.ClaimDueDelivery(ctx, time.Now(), webhookClaimLease)
+		if err != nil {
+			log.Printf("[Webhook] %v", err)
+			break
+		}
+		if delivery == nil {
+			break
+		}
+		s.attempt(ctx, *delivery)
+		attempted++
+	}
+	return attempted
+}
+
+// attempt sends one delivery and schedules the next try with exponential backoff
+// when it fails.
+func (s *WebhookService) attempt(ctx context.Context, delivery model.WebhookDelivery) {
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.LastStatusCode = 0
+	delivery.LastError = ""
+
+	endpoint, err := s.repo.GetEndpoint(ctx, delivery.EndpointID)
+	switch {
+	case err != nil:
+		delivery.LastError = err.Error()
+	case !endpoint.Active:
+		delivery.LastError = "endpoint disabled"
+	default:
+		delivery.LastStatusCode, err = s.send(ctx, endpoint, delivery)
+		if err != nil {
+			delivery.LastError = err.Error()
+		}
+	}
+
+	switch {
+	case delivery.LastError == "":
+		delivery.Status = model.WebhookDeliverySucceeded
+	case delivery.Attempts >= s.maxAttempts:
+		delivery.Status = model.WebhookDeliveryFailed
+		log.Printf("[Webhook] Delivery %s of %s gave up after %d attempts: %s",
+			delivery.ID.Hex(), delivery.EventType, delivery.Attempts, delivery.LastError)
+	default:
+		delivery.Status = model.WebhookDeliveryPending
+		delivery.NextAttemptAt = now.Add(s.backoff(delivery.Attempts))
+	}
+
+	if err := s.repo.RecordAttempt(ctx, delivery); err != nil {
+		log.Printf("[Webhook] %v", err)
+	}
+}
+
+func (s *WebhookService) send(ctx context.Context, endpoint model.WebhookEndpoint, delivery model.WebhookDelivery) (int, error) {
+	// Endpoints stored before URLs were restricted may still point somewhere private.
+	if err := validateWebhookURL(endpoint.URL); err != nil {
+		return 0, err
+	}
+
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	signature := utils.ComputeHMACSHA256(endpoint.Secret, []byte(timestamp+"."+delivery.Payload))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.Hex())
+	req.Header.Set(WebhookSignatureHeader, fmt.Sprintf("t=%s,v1=%s", timestamp, signature))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the wait before the next attempt: baseDelay doubled for every
+// earlier attempt, capped at maxDelay.
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	delay := s.baseDelay
+	for i := 1; i < attempts && delay < s.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.maxDelay {
+		delay = s.maxDelay
+	}
+	return delay
+}
+
+func (s *WebhookService) CreateEndpoint(ctx context.Context, req dto.CreateWebhookEndpointRequest) (model.WebhookEndpoint, error) {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return model.WebhookEndpoint{}, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return model.WebhookEndpoint{}, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		secret = "whsec_" + hex.EncodeToString(buf)
+	}
+
+	events := req.Events
+	if events == nil {
+		events = []string{}
+	}
+
+	now := time.Now()
+	return s.repo.CreateEndpoint(ctx, model.WebhookEndpoint{
+		CreatedBy:   utils.UserIDFromContext(ctx),
+		URL:         req.URL,
+		Secret:      secret,
+		Events:      events,
+		Description: req.Description,
+		Active:      true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+}
+
+// ListEndpoints returns the caller's endpoints with their secrets removed.
+func (s *WebhookService) ListEndpoints(ctx context.Context) ([]model.WebhookEndpoint, error) {
+	endpoints, err := s.repo.ListEndpoints(ctx, utils.UserIDFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	for i := range endpoints {
+		endpoints[i].Secret = ""
+	}
+	return endpoints, nil
+}
+
+// GetEndpoint returns one of the caller's endpoints with its secret removed. Other
+// users' endpoints are reported as not found.
+func (s *WebhookService) GetEndpoint(ctx context.Context, id string) (model.WebhookEndpoint, error) {
+	objectID, err := parseWebhookID(id)
+	if err != nil {
+		return model.WebhookEndpoint{}, err
+	}
+	endpoint, err := s.repo.GetEndpoint(ctx, objectID)
+	if err != nil {
+		return model.WebhookEndpoint{}, err
+	}
+	if endpoint.CreatedBy != utils.UserIDFromContext(ctx) {
+		return model.WebhookEndpoint{}, repository.ErrWebhookEndpointNotFound
+	}
+	endpoint.Secret = ""
+	return endpoint, nil
+}
+
+func (s *WebhookService) UpdateEndpoint(ctx context.Context, id string, req dto.UpdateWebhookEndpointRequest) (model.WebhookEndpoint, error) {
+	objectID, err := parseWebhookID(id)
+	if err != nil {
+		return model.WebhookEndpoint{}, err
+	}
+
+	fields := bson.M{}
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return model.WebhookEndpoint{}, err
+		}
+		fields["url"] = *req.URL
+	}
+	if req.Events != nil {
+		fields["events"] = req.Events
+	}
+	if req.Description != nil {
+		fields["description"] = *req.Description
+	}
+	if req.Active != nil {
+		fields["active"] = *req.Active
+	}
+
+	endpoint, err := s.repo.UpdateEndpoint(ctx, objectID, utils.UserIDFromContext(ctx), fields)
+	if err != nil {
+		return model.WebhookEndpoint{}, err
+	}
+	endpoint.Secret = ""
+	return endpoint, nil
+}
+
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, id string) error {
+	objectID, err := parseWebhookID(id)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteEndpoint(ctx, objectID, utils.UserIDFromContext(ctx))
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, endpointID, status, eventType, cursor string, limit int64) (dto.WebhookDeliveryListResponse, error) {
+	if endpointID != "" {
+		if _, err := parseWebhookID(endpointID); err != nil {
+			return dto.WebhookDeliveryListResponse{}, err
+		}
+	}
+
+	deliveries, err := s.repo.ListDeliveries(ctx, utils.UserIDFromContext(ctx), endpointID, status, eventType, cursor, limit)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			return dto.WebhookDeliveryListResponse{}, fmt.Errorf("%w: invalid cursor", ErrInvalidWebhookRequest)
+		}
+		return dto.WebhookDeliveryListResponse{}, err
+	}
+
+	resp := dto.WebhookDeliveryListResponse{Deliveries: deliveries}
+	if int64(len(deliveries)) == limit {
+		resp.NextCursor = deliveries[len(deliveries)-1].ID.Hex()
+	}
+	return resp, nil
+}
+
+func (s *WebhookService) GetDelivery(ctx context.Context, id string) (model.WebhookDelivery, error) {
+	objectID, err := parseWebhookID(id)
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+	return s.repo.GetDelivery(ctx, objectID, utils.UserIDFromContext(ctx))
+}
+
+// ReplayDelivery queues a delivery to be sent again on the next dispatch, whatever its outcome so far.
+func (s *WebhookService) ReplayDelivery(ctx context.Context, id string) (model.WebhookDelivery, error) {
+	objectID, err := parseWebhookID(id)
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+	return s.repo.RequeueDelivery(ctx, objectID, utils.UserIDFromContext(ctx))
+}
+
+func parseWebhookID(id string) (primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("%w: invalid id %q", ErrInvalidWebhookRequest, id)
+	}
+	return objectID, nil
+}
+
+// validateWebhookURL accepts absolute https URLs whose host is not a loopback,
+// private or link-local address. Hostnames are checked again when dialled, since they
+// can resolve to anything.
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("%w: url must be an absolute https URL", ErrInvalidWebhookRequest)
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: url must not point at a local host", ErrInvalidWebhookRequest)
+	}
+	if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+		return fmt.Errorf("%w: url must not point at a private address", ErrInvalidWebhookRequest)
+	}
+	return nil
+}
+
+// nonPublicNetworks are ranges isPublicIP rejects beyond those the net package
+// classifies: "this network" and carrier-grade NAT.
+var nonPublicNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+}
+
+func mustParseCIDR(s string) *net.IPNet {
+	_, network, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// isPublicIP reports whether webhooks may be sent to ip. Loopback, private, link-local
+// (including cloud metadata services), multicast and unspecified addresses are refused.
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// newWebhookClient returns a client that refuses to connect to non-public addresses,
+// whatever the endpoint's hostname resolves to, and does not follow redirects.
+func newWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: refuseNonPublicAddress}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			ForceAttemptHTTP2:   true,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// refuseNonPublicAddress is a net.Dialer Control hook that runs after DNS resolution,
+// so it also catches hostnames that resolve to private addresses.
+func refuseNonPublicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("refusing to connect to non-public address %s", host)
+	}
+	return nil
+}
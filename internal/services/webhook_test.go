@@ -0,0 +1,61 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://merchant.example.com/hooks", false},
+		{"https://93.184.216.34/hooks", false},
+		{"https://[2606:2800:220:1:248:1893:25c8:1946]/hooks", false},
+		{"http://merchant.example.com/hooks", true},
+		{"ftp://merchant.example.com/hooks", true},
+		{"/hooks", true},
+		{"https://localhost/hooks", true},
+		{"https://LOCALHOST./hooks", true},
+		{"https://api.localhost/hooks", true},
+		{"https://127.0.0.1/hooks", true},
+		{"https://[::1]/hooks", true},
+		{"https://[::ffff:127.0.0.1]/hooks", true},
+		{"https://0.0.0.0/hooks", true},
+		{"https://169.254.169.254/latest/meta-data", true},
+		{"https://[fe80::1]/hooks", true},
+		{"https://10.0.0.5/hooks", true},
+		{"https://172.16.3.4/hooks", true},
+		{"https://192.168.1.1/hooks", true},
+		{"https://[fd00::1]/hooks", true},
+		{"https://100.64.0.1/hooks", true},
+	}
+	for _, tt := range tests {
+		err := validateWebhookURL(tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateWebhookURL(%q) = %v, want error %v", tt.url, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidWebhookRequest) {
+			t.Errorf("validateWebhookURL(%q) = %v, want ErrInvalidWebhookRequest", tt.url, err)
+		}
+	}
+}
+
+func TestRefuseNonPublicAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{"93.184.216.34:443", false},
+		{"127.0.0.1:443", true},
+		{"169.254.169.254:80", true},
+		{"10.1.2.3:443", true},
+		{"[::1]:443", true},
+	}
+	for _, tt := range tests {
+		if err := refuseNonPublicAddress("tcp", tt.address, nil); (err != nil) != tt.wantErr {
+			t.Errorf("refuseNonPublicAddress(%q) = %v, want error %v", tt.address, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/dto"
+	"github.com/google/uuid"
+)
+
+// DBS notification kinds and the gateway path, relative to /api/dbs, each is pushed to.
+const (
+	DBSKindIntraday  = "intraday"
+	DBSKindIncoming  = "incoming"
+	DBSKindStatement = "statement"
+)
+
+var dbsPaths = map[string]string{
+	DBSKindIntraday:  "/intraday/notification",
+	DBSKindIncoming:  "/incoming/notification",
+	DBSKindStatement: "/bank-statement",
+}
+
+// DBSPush describes a notification to push into the gateway.
+type DBSPush struct {
+	// GatewayURL is the base of the gateway's DBS routes, e.g. http://localhost:8080/api/dbs.
+	GatewayURL string `json:"gateway_url"`
+	Kind       string `json:"kind"`
+	// Token is sent as a bearer token; the DBS routes sit behind AuthMiddleware.
+	Token    string `json:"token"`
+	Amount   string `json:"amount,omitempty"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// PushDBSNotification sends a sample DBS notification of the requested kind to the gateway
+// and returns the gateway's status code.
+func PushDBSNotification(ctx context.Context, client *http.Client, push DBSPush) (int, error) {
+	path, ok := dbsPaths[push.Kind]
+	if !ok {
+		return 0, fmt.Errorf("unknown DBS notification kind %q", push.Kind)
+	}
+	if push.Amount == "" {
+		push.Amount = "100.00"
+	}
+	if push.Currency == "" {
+		push.Currency = "SGD"
+	}
+
+	body, err := json.Marshal(dbsPayload(push))
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal DBS payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(push.GatewayURL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build DBS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if push.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+push.Token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to push DBS notification: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode, nil
+}
+
+func dbsPayload(push DBSPush) interface{} {
+	now := time.Now().UTC()
+	header := dto.Header{
+		MsgID:     "SIM" + strings.ToUpper(uuid.New().String()[:8]),
+		OrgID:     "SIMORG",
+		TimeStamp: now.Format(time.RFC3339),
+		Country:   "SG",
+	}
+
+	if push.Kind == DBSKindStatement {
+		return statementPayload(header, push, now)
+	}
+
+	txnType := "INCOMING"
+	if push.Kind == DBSKindIntraday {
+		txnType = "INTRADAY"
+	}
+	txnInfo := dto.TxnInfo{
+		TxnType:           txnType,
+		CustomerReference: "SIMREF" + strconv.FormatInt(now.Unix(), 10),
+		TxnRefID:          uuid.New().String(),
+		TxnDate:           now.Format("2006-01-02"),
+		ValueDate:         now.Format("2006-01-02"),
+		ReceivingParty:    dto.ReceivingParty{Name: "Payment Gateway", AccountNo: "0011223344"},
+		AmountDetails:     dto.NotificationAmountDetails{TxnCurrency: push.Currency, TxnAmount: push.Amount},
+		SenderParty:       dto.SenderParty{Name: "Simulated Sender", AccountNo: "9988776655", SenderBankID: "DBSSSGSG"},
+		PaymentDetails:    "Simulated payment",
+	}
+
+	if push.Kind == DBSKindIntraday {
+		return dto.IntradayNotificationPayload{Header: header, TxnInfo: txnInfo}
+	}
+	return dto.IncomingNotificationPayload{Header: header, TxnInfo: txnInfo}
+}
+
+func statementPayload(header dto.Header, push DBSPush, now time.Time) dto.CAMT053Request {
+	amount, _ := strconv.ParseFloat(push.Amount, 64)
+	date := now.Format("2006-01-02")
+	dbsAmount := dto.DbsAmount{Value: amount, Ccy: push.Currency}
+
+	return dto.CAMT053Request{
+		Header: header,
+		TxnEnqResponse: dto.TxnEnqResponse{
+			EnqStatus:   "ACSP",
+			AcctInfo:    dto.AcctInfo{AccountNo: "0011223344", AccountCcy: push.Currency},
+			BizDate:     date,
+			MessageType: "CAMT053",
+			Statement: []dto.StatementWrapper{{
+				BkToCstmrStmt: dto.BankToCustomerStatement{
+					GrpHdr: dto.GroupHeader{MsgID: header.MsgID, CreDtTm: header.TimeStamp},
+					Stmt: []dto.Statement{{
+						ID:      "STMT" + header.MsgID,
+						CreDtTm: header.TimeStamp,
+						Acct: dto.Account{
+							ID:  dto.AccountID{Othr: dto.IDValue{ID: "0011223344"}},
+							Ccy: push.Currency,
+							Nm:  "Payment Gateway",
+						},
+						Bal: []dto.Balance{{
+							Tp:        dto.BalanceType{CdOrPrtry: dto.CodeOrProprietary{Cd: "CLBD"}},
+							Amt:       dbsAmount,
+							CdtDbtInd: "CRDT",
+							Dt:        dto.DateObj{Dt: date},
+						}},
+						TxsSumm: dto.TxnSummary{TtlNtries: dto.TotalEntries{
+							NbOfNtries: "1", Sum: amount, TtlNetNtryAmt: amount, CdtDbtInd: "CRDT",
+						}},
+						Ntry: []dto.Entry{{
+							NtryRef:   "NTRY" + header.MsgID,
+							Amt:       dbsAmount,
+							CdtDbtInd: "CRDT",
+							Sts:       "BOOK",
+							BookgDt:   dto.DateTimeObj{DtTm: header.TimeStamp},
+							ValDt:     dto.DateObj{Dt: date},
+						}},
+					}},
+				},
+			}},
+		},
+	}
+}
+
+func (s *Simulator) dbsPushHandler(w http.ResponseWriter, r *http.Request) {
+	var push DBSPush
+	if err := json.NewDecoder(r.Body).Decode(&push); err != nil || push.GatewayURL == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "gateway_url and kind are required")
+		return
+	}
+
+	status, err := PushDBSNotification(r.Context(), http.DefaultClient, push)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "PUSH_FAILED", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"gateway_status": status})
+}
@@ -0,0 +1,372 @@
+package simulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/constants"
+	"github.com/aakritigkmit/payment-gateway/internal/dto"
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// DT One transaction statuses used by the simulator.
+var (
+	dtOneStatusCreated   = model.Status{ID: 10000, Message: "CREATED", Class: model.StatusClass{ID: 1, Message: "CREATED"}}
+	dtOneStatusConfirmed = model.Status{ID: 20000, Message: "CONFIRMED", Class: model.StatusClass{ID: 2, Message: "CONFIRMED"}}
+	dtOneStatusCancelled = model.Status{ID: 40000, Message: "CANCELLED", Class: model.StatusClass{ID: 4, Message: "CANCELLED"}}
+	dtOneStatusCompleted = model.Status{ID: 60000, Message: "COMPLETED", Class: model.StatusClass{ID: 6, Message: "COMPLETED"}}
+)
+
+type simOperator struct {
+	id      int
+	name    string
+	country model.Country
+	service model.Service
+	// currency is the destination currency of the operator's products.
+	currency string
+	types    []string
+}
+
+// simOperators includes the operators the gateway syncs by default.
+var simOperators = []simOperator{
+	{
+		id: constants.ProductOperatorId["Roblox"], name: "Roblox",
+		country:  model.Country{ISOCode: constants.ProductCountryISOCode.Global, Name: "Global"},
+		service:  model.Service{ID: constants.ProductServiceIDs.GiftCards, Name: "Gift Cards"},
+		currency: "USD",
+		types:    []string{constants.ProductTypes.FixedValuePinPurchase, constants.ProductTypes.RangedValuePinPurchase},
+	},
+	{
+		id: constants.ProductOperatorId["Guatemala"], name: "Guatemala Gift Cards",
+		country:  model.Country{ISOCode: "GTM", Name: "Guatemala"},
+		service:  model.Service{ID: constants.ProductServiceIDs.GiftCards, Name: "Gift Cards"},
+		currency: "GTQ",
+		types:    []string{constants.ProductTypes.FixedValuePinPurchase},
+	},
+	{
+		id: 1707, name: "Airtel India",
+		country:  model.Country{ISOCode: constants.ProductCountryISOCode.India, Name: "India"},
+		service:  model.Service{ID: constants.ProductServiceIDs.Mobile, Name: "Mobile"},
+		currency: "INR",
+		types:    []string{constants.ProductTypes.FixedValueRecharge, constants.ProductTypes.RangedValueRecharge},
+	},
+}
+
+// buildCatalog generates a deterministic product catalog.
+func buildCatalog(perOperator int) []model.Product {
+	products := make([]model.Product, 0, perOperator*len(simOperators))
+	for o, op := range simOperators {
+		for i := 0; i < perOperator; i++ {
+			productType := op.types[i%len(op.types)]
+			value := 5 * (i%20 + 1)
+			id := (o+1)*100000 + i + 1
+
+			product := model.Product{
+				UniqueId:    id,
+				Name:        fmt.Sprintf("%s %d", op.name, value),
+				Description: fmt.Sprintf("Simulated %s product", strings.ToLower(productType)),
+				Type:        productType,
+				Operator:    model.Operator{ID: op.id, Name: op.name, Country: op.country},
+				Service:     op.service,
+				Destination: model.ProductAmount{Unit: op.currency, UnitType: "CURRENCY"},
+				Source:      model.ProductAmount{Unit: "USD", UnitType: "CURRENCY"},
+				Prices: model.Prices{
+					Retail:    value,
+					Wholesale: model.Wholesale{Amount: float64(value) * 0.95, Fee: 0, Unit: "USD", UnitType: "CURRENCY"},
+				},
+				AvailabilityZones:                   []string{"INTERNATIONAL"},
+				RequiredCreditPartyIdentifierFields: [][]string{{"mobile_number"}},
+				Validity:                            model.Validity{Quantity: 365, Unit: "DAY"},
+			}
+			if product.IsRanged() {
+				product.Source.MinAmount, product.Source.MaxAmount = floatPtr(1), floatPtr(float64(value))
+				product.Destination.MinAmount, product.Destination.MaxAmount = floatPtr(1), floatPtr(float64(value))
+			} else {
+				product.Source.Amount, product.Destination.Amount = floatPtr(float64(value)), floatPtr(float64(value))
+			}
+			if strings.Contains(productType, "PIN_PURCHASE") {
+				product.RequiredCreditPartyIdentifierFields = [][]string{}
+			}
+			products = append(products, product)
+		}
+	}
+	return products
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func requireBasicAuth(w http.ResponseWriter, r *http.Request) bool {
+	if _, _, ok := r.BasicAuth(); !ok {
+		writeDTOneError(w, http.StatusUnauthorized, 1000401, "Unauthorized")
+		return false
+	}
+	return true
+}
+
+func (s *Simulator) dtOneProducts(w http.ResponseWriter, r *http.Request) {
+	if !requireBasicAuth(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	page := queryInt(query.Get("page"), 1)
+	perPage := queryInt(query.Get("per_page"), 50)
+	if perPage > 100 {
+		perPage = 100
+	}
+	serviceID := queryInt(query.Get("service_id"), 0)
+	operatorID := queryInt(query.Get("operator_id"), 0)
+	country := query.Get("country_iso_code")
+	productType := query.Get("type")
+
+	matched := make([]model.Product, 0)
+	for _, p := range s.products {
+		if serviceID != 0 && p.Service.ID != serviceID {
+			continue
+		}
+		if operatorID != 0 && p.Operator.ID != operatorID {
+			continue
+		}
+		if country != "" && p.Operator.Country.ISOCode != country {
+			continue
+		}
+		if productType != "" && p.Type != productType {
+			continue
+		}
+		matched = append(matched, p)
+	}
+
+	totalPages := (len(matched) + perPage - 1) / perPage
+	if totalPages == 0 {
+		totalPages = 1
+	}
+	start := (page - 1) * perPage
+	if start > len(matched) {
+		start = len(matched)
+	}
+	end := start + perPage
+	if end > len(matched) {
+		end = len(matched)
+	}
+
+	w.Header().Set("X-Page", strconv.Itoa(page))
+	w.Header().Set("X-Per-Page", strconv.Itoa(perPage))
+	w.Header().Set("X-Total", strconv.Itoa(len(matched)))
+	w.Header().Set("X-Total-Pages", strconv.Itoa(totalPages))
+	writeJSON(w, http.StatusOK, matched[start:end])
+}
+
+func (s *Simulator) dtOneCreateTransaction(w http.ResponseWriter, r *http.Request) {
+	if !requireBasicAuth(w, r) {
+		return
+	}
+
+	var req dto.DTOneTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ExternalID == "" {
+		writeDTOneError(w, http.StatusBadRequest, 1000400, "external_id and product_id are required")
+		return
+	}
+
+	product, ok := s.findProduct(req.ProductID)
+	if !ok {
+		writeDTOneError(w, http.StatusBadRequest, 1003001, "Product not found")
+		return
+	}
+	if product.IsRanged() {
+		if msg := checkRangedAmount(product, req); msg != "" {
+			writeDTOneError(w, http.StatusBadRequest, 1003003, msg)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.transactions[req.ExternalID]; exists {
+		writeDTOneError(w, http.StatusBadRequest, 1003002, "External ID already used")
+		return
+	}
+
+	now := time.Now().UTC()
+	s.nextTxID++
+	tx := &model.ProductTransaction{
+		ID:                         s.nextTxID,
+		ExternalID:                 req.ExternalID,
+		CreationDate:               now,
+		ConfirmationExpirationDate: now.Add(time.Hour),
+		CreditPartyIdentifier:      model.CreditPartyIdentifier{MobileNumber: req.CreditPartyIdentifier["mobile_number"]},
+		Product:                    product,
+		Prices:                     product.Prices,
+		Status:                     dtOneStatusCreated,
+		OperatorReference:          "sim-" + uuid.New().String()[:12],
+	}
+	if req.AutoConfirm {
+		completeTransaction(tx, now)
+	}
+	s.transactions[req.ExternalID] = tx
+	if req.CallbackURL != "" {
+		s.callbackURLs[req.ExternalID] = req.CallbackURL
+		s.sendDTOneCallback(tx)
+	}
+
+	writeJSON(w, http.StatusCreated, tx)
+}
+
+// checkRangedAmount validates the requested amount of a ranged-value transaction the
+// way DT One does, returning an error message or "".
+func checkRangedAmount(product model.Product, req dto.DTOneTransactionRequest) string {
+	var (
+		requested *dto.DTOneAmount
+		side      model.ProductAmount
+	)
+	switch req.CalculationMode {
+	case constants.CalculationModes.SourceAmount:
+		requested, side = req.Source, product.Source
+	case constants.CalculationModes.DestinationAmount:
+		requested, side = req.Destination, product.Destination
+	default:
+		return "calculation_mode is required for ranged-value products"
+	}
+	if requested == nil {
+		return "amount is required for calculation_mode " + req.CalculationMode
+	}
+	if requested.Unit != side.Unit {
+		return fmt.Sprintf("unit must be %s", side.Unit)
+	}
+	if (side.MinAmount != nil && requested.Amount < *side.MinAmount) || (side.MaxAmount != nil && requested.Amount > *side.MaxAmount) {
+		return "amount is out of range"
+	}
+	return ""
+}
+
+// completeTransaction marks tx as delivered, issuing a PIN for PIN products.
+func completeTransaction(tx *model.ProductTransaction, now time.Time) {
+	tx.ConfirmationDate = now
+	tx.Status = dtOneStatusCompleted
+	if strings.Contains(tx.Product.Type, "PIN_PURCHASE") {
+		tx.Pin = model.Pin{
+			Code:   strings.ToUpper(strings.ReplaceAll(uuid.New().String(), "-", "")[:16]),
+			Serial: fmt.Sprintf("SIM%010d", tx.ID),
+		}
+	}
+}
+
+// dtOneConfirmTransaction confirms a transaction created without auto-confirm. The
+// simulator delivers it straight away.
+func (s *Simulator) dtOneConfirmTransaction(w http.ResponseWriter, r *http.Request) {
+	s.updateCreatedTransaction(w, r, func(tx *model.ProductTransaction, now time.Time) {
+		tx.Status = dtOneStatusConfirmed
+		completeTransaction(tx, now)
+	})
+}
+
+func (s *Simulator) dtOneCancelTransaction(w http.ResponseWriter, r *http.Request) {
+	s.updateCreatedTransaction(w, r, func(tx *model.ProductTransaction, now time.Time) {
+		tx.Status = dtOneStatusCancelled
+	})
+}
+
+// updateCreatedTransaction applies update to the transaction named in the path if it is
+// still awaiting confirmation and its confirmation has not expired.
+func (s *Simulator) updateCreatedTransaction(w http.ResponseWriter, r *http.Request, update func(*model.ProductTransaction, time.Time)) {
+	if !requireBasicAuth(w, r) {
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeDTOneError(w, http.StatusNotFound, 1003404, "Transaction not found")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tx *model.ProductTransaction
+	for _, candidate := range s.transactions {
+		if candidate.ID == id {
+			tx = candidate
+			break
+		}
+	}
+	if tx == nil {
+		writeDTOneError(w, http.StatusNotFound, 1003404, "Transaction not found")
+		return
+	}
+
+	now := time.Now().UTC()
+	if tx.Status.Class.ID != dtOneStatusCreated.Class.ID || now.After(tx.ConfirmationExpirationDate) {
+		writeDTOneError(w, http.StatusBadRequest, 1003005, "Transaction is not awaiting confirmation")
+		return
+	}
+	update(tx, now)
+	s.sendDTOneCallback(tx)
+	writeJSON(w, http.StatusOK, tx)
+}
+
+// sendDTOneCallback posts the transaction's current state to its callback URL, if it
+// has one. Credentials in the URL are sent as Basic auth. Callers hold s.mu.
+func (s *Simulator) sendDTOneCallback(tx *model.ProductTransaction) {
+	callbackURL, ok := s.callbackURLs[tx.ExternalID]
+	if !ok {
+		return
+	}
+	body, err := json.Marshal(tx)
+	if err != nil {
+		return
+	}
+
+	externalID := tx.ExternalID
+	go func() {
+		resp, err := http.Post(callbackURL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("[Simulator] DT One callback for %s failed: %v", externalID, err)
+			return
+		}
+		resp.Body.Close()
+	}()
+}
+
+func (s *Simulator) dtOneGetTransactions(w http.ResponseWriter, r *http.Request) {
+	if !requireBasicAuth(w, r) {
+		return
+	}
+
+	externalID := r.URL.Query().Get("external_id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	txs := make([]model.ProductTransaction, 0)
+	for id, tx := range s.transactions {
+		if externalID == "" || id == externalID {
+			txs = append(txs, *tx)
+		}
+	}
+	writeJSON(w, http.StatusOK, txs)
+}
+
+func (s *Simulator) findProduct(id int) (model.Product, bool) {
+	for _, p := range s.products {
+		if p.UniqueId == id {
+			return p, true
+		}
+	}
+	return model.Product{}, false
+}
+
+func queryInt(value string, def int) int {
+	if n, err := strconv.Atoi(value); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
@@ -0,0 +1,139 @@
+package simulator
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/config"
+)
+
+// Fault makes matching requests misbehave. A fault with only DelayMs set slows the
+// request down and then lets the simulated API answer normally.
+type Fault struct {
+	// Method and PathPrefix select requests; empty values match everything.
+	Method     string `json:"method,omitempty"`
+	PathPrefix string `json:"path_prefix,omitempty"`
+
+	// Status, when non-zero, is returned instead of the real response (e.g. 429, 503).
+	Status int `json:"status,omitempty"`
+	// RetryAfter sets the Retry-After header, in seconds, on injected responses.
+	RetryAfter int `json:"retry_after,omitempty"`
+	// DelayMs delays the response.
+	DelayMs int `json:"delay_ms,omitempty"`
+	// Malformed answers 200 with a truncated JSON body.
+	Malformed bool `json:"malformed,omitempty"`
+
+	// Times limits how many requests the fault applies to; 0 means until cleared.
+	Times int `json:"times,omitempty"`
+	// Hits counts the requests the fault has applied to so far.
+	Hits int `json:"hits"`
+}
+
+func (f *Fault) matches(r *http.Request) bool {
+	if f.Times > 0 && f.Hits >= f.Times {
+		return false
+	}
+	if f.Method != "" && !strings.EqualFold(f.Method, r.Method) {
+		return false
+	}
+	return strings.HasPrefix(r.URL.Path, f.PathPrefix)
+}
+
+// AddFault scripts a failure. Faults are checked in the order they were added.
+func (s *Simulator) AddFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes every scripted failure.
+func (s *Simulator) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// takeFault returns a copy of the first fault matching r and counts the hit.
+func (s *Simulator) takeFault(r *http.Request) (Fault, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range s.faults {
+		if f.matches(r) {
+			f.Hits++
+			return *f, true
+		}
+	}
+	return Fault{}, false
+}
+
+// applyFaults is middleware injecting scripted failures into provider endpoints.
+func (s *Simulator) applyFaults(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/_sim") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		fault, ok := s.takeFault(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if fault.DelayMs > 0 {
+			select {
+			case <-time.After(time.Duration(fault.DelayMs) * time.Millisecond):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		switch {
+		case fault.Malformed:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"data": {"order_id": "trunc`))
+		case fault.Status != 0:
+			if fault.RetryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(fault.RetryAfter))
+			}
+			if strings.HasPrefix(r.URL.Path, config.SimulatorDtOnePath) {
+				writeDTOneError(w, fault.Status, fault.Status*1000, http.StatusText(fault.Status))
+			} else {
+				writeError(w, fault.Status, "SIMULATED_FAULT", http.StatusText(fault.Status))
+			}
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+func (s *Simulator) listFaultsHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	faults := make([]Fault, 0, len(s.faults))
+	for _, f := range s.faults {
+		faults = append(faults, *f)
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, faults)
+}
+
+func (s *Simulator) addFaultHandler(w http.ResponseWriter, r *http.Request) {
+	var f Fault
+	if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid fault")
+		return
+	}
+	f.Hits = 0
+	s.AddFault(f)
+	writeJSON(w, http.StatusCreated, f)
+}
+
+func (s *Simulator) clearFaultsHandler(w http.ResponseWriter, r *http.Request) {
+	s.ClearFaults()
+	w.WriteHeader(http.StatusNoContent)
+}
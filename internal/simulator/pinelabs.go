@@ -0,0 +1,359 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/dto"
+	"github.com/aakritigkmit/payment-gateway/internal/money"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func requireBearer(w http.ResponseWriter, r *http.Request) bool {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "missing bearer token")
+		return false
+	}
+	return true
+}
+
+func (s *Simulator) pineToken(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ClientID == "" || body.ClientSecret == "" {
+		writeError(w, http.StatusUnauthorized, "INVALID_CLIENT", "client_id and client_secret are required")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "sim_" + uuid.New().String(),
+		"expires_at":   time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	})
+}
+
+func (s *Simulator) pineCreateOrder(w http.ResponseWriter, r *http.Request) {
+	if !requireBearer(w, r) {
+		return
+	}
+
+	var req dto.PlaceOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if err := req.OrderAmount.Validate(); err != nil || req.OrderAmount.IsZero() {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "order_amount is invalid")
+		return
+	}
+
+	now := time.Now().UTC()
+	order := &dto.PineOrderData{
+		OrderID:                "v1-sim-" + uuid.New().String(),
+		MerchantOrderReference: fmt.Sprint(req.MerchantOrderReference),
+		Type:                   "CHARGE",
+		Status:                 "CREATED",
+		CallbackURL:            req.CallbackURL,
+		FailureCallbackURL:     req.FailureCallbackURL,
+		MerchantID:             "sim-merchant",
+		OrderAmount:            req.OrderAmount,
+		Notes:                  req.Notes,
+		PreAuth:                req.PreAuth,
+		AllowedPaymentMethods:  req.AllowedPaymentMethods,
+		PurchaseDetails:        req.PurchaseDetails,
+		Payments:               []dto.Payment{},
+		Refunds:                []dto.Refund{},
+		CreatedAt:              now,
+		UpdatedAt:              now,
+		IntegrationMode:        "REDIRECT",
+	}
+
+	s.mu.Lock()
+	s.pineOrders[order.OrderID] = order
+	baseURL := s.baseURL
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"token":            "sim_checkout_" + uuid.New().String(),
+		"order_id":         order.OrderID,
+		"redirect_url":     baseURL + "/pinelabs/checkout/" + order.OrderID,
+		"response_code":    200,
+		"response_message": "Order Creation Successful",
+	})
+}
+
+func (s *Simulator) pineGetOrder(w http.ResponseWriter, r *http.Request) {
+	if !requireBearer(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.pineOrders[chi.URLParam(r, "id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "ORDER_NOT_FOUND", "order not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.PineOrderResponse{Data: *order})
+}
+
+func (s *Simulator) pineCaptureOrder(w http.ResponseWriter, r *http.Request) {
+	if !requireBearer(w, r) {
+		return
+	}
+
+	var req struct {
+		MerchantCaptureReference string          `json:"merchant_capture_reference"`
+		CaptureAmount            dto.OrderAmount `json:"capture_amount"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.pineOrders[chi.URLParam(r, "id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "ORDER_NOT_FOUND", "order not found")
+		return
+	}
+	if order.Status != "AUTHORIZED" {
+		writeError(w, http.StatusBadRequest, "INVALID_ORDER_STATE", "order is not authorized")
+		return
+	}
+	if req.CaptureAmount.Value <= 0 || req.CaptureAmount.Value > order.OrderAmount.Value {
+		writeError(w, http.StatusBadRequest, "INVALID_AMOUNT", "capture amount exceeds authorized amount")
+		return
+	}
+
+	for i := range order.Payments {
+		order.Payments[i].Status = "PROCESSED"
+		order.Payments[i].PaymentAmount = req.CaptureAmount
+	}
+	s.setPineOrderStatus(order, "PROCESSED")
+	writeJSON(w, http.StatusOK, dto.PineOrderResponse{Data: *order})
+}
+
+func (s *Simulator) pineCancelOrder(w http.ResponseWriter, r *http.Request) {
+	if !requireBearer(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.pineOrders[chi.URLParam(r, "id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "ORDER_NOT_FOUND", "order not found")
+		return
+	}
+	switch order.Status {
+	case "CREATED", "PENDING", "AUTHORIZED":
+	default:
+		writeError(w, http.StatusBadRequest, "INVALID_ORDER_STATE", "order cannot be cancelled in status "+order.Status)
+		return
+	}
+
+	for i := range order.Payments {
+		order.Payments[i].Status = "CANCELLED"
+	}
+	s.setPineOrderStatus(order, "CANCELLED")
+	writeJSON(w, http.StatusOK, dto.PineOrderResponse{Data: *order})
+}
+
+func (s *Simulator) pineRefund(w http.ResponseWriter, r *http.Request) {
+	if !requireBearer(w, r) {
+		return
+	}
+
+	var req struct {
+		MerchantOrderReference string            `json:"merchant_order_reference"`
+		OrderAmount            dto.OrderAmount   `json:"order_amount"`
+		MerchantMetadata       map[string]string `json:"merchant_metadata"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.pineOrders[chi.URLParam(r, "id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "ORDER_NOT_FOUND", "order not found")
+		return
+	}
+	if order.Status != "PROCESSED" && order.Status != "PARTIALLY_REFUNDED" {
+		writeError(w, http.StatusBadRequest, "INVALID_ORDER_STATE", "order is not refundable in status "+order.Status)
+		return
+	}
+	if !strings.EqualFold(req.OrderAmount.Currency, order.OrderAmount.Currency) {
+		writeError(w, http.StatusBadRequest, "INVALID_CURRENCY", "refund currency does not match order currency")
+		return
+	}
+	if req.OrderAmount.Value <= 0 || req.OrderAmount.Value > order.OrderAmount.Value-refundedAmount(order) {
+		writeError(w, http.StatusBadRequest, "INVALID_AMOUNT", "refund amount exceeds refundable balance")
+		return
+	}
+
+	now := time.Now().UTC()
+	refund := dto.Refund{
+		MerchantOrderReference: req.MerchantOrderReference,
+		OrderID:                "v1-ref-" + uuid.New().String(),
+		Type:                   "REFUND",
+		Status:                 s.opts.RefundStatus,
+		OrderAmount:            req.OrderAmount,
+		Payments:               []dto.Payment{},
+		CreatedAt:              now.Format(time.RFC3339),
+		UpdatedAt:              now.Format(time.RFC3339),
+	}
+	order.Refunds = append(order.Refunds, refund)
+	s.updateRefundedStatus(order)
+
+	writeJSON(w, http.StatusOK, dto.RefundOrderResponse{Data: dto.RefundOrderData{
+		OrderID:                refund.OrderID,
+		ParentOrderID:          order.OrderID,
+		MerchantOrderReference: refund.MerchantOrderReference,
+		Type:                   refund.Type,
+		Status:                 refund.Status,
+		MerchantID:             order.MerchantID,
+		OrderAmount:            refund.OrderAmount,
+		PurchaseDetails: dto.PurchaseDetails{
+			Customer:         order.PurchaseDetails.Customer,
+			MerchantMetadata: req.MerchantMetadata,
+		},
+		Payments:        []dto.Payment{},
+		CreatedAt:       refund.CreatedAt,
+		UpdatedAt:       refund.UpdatedAt,
+		IntegrationMode: order.IntegrationMode,
+	}})
+}
+
+// refundedAmount sums refunds that are not failed or cancelled.
+func refundedAmount(order *dto.PineOrderData) money.Amount {
+	var total money.Amount
+	for _, refund := range order.Refunds {
+		if refund.Status != "FAILED" && refund.Status != "CANCELLED" {
+			total += refund.OrderAmount.Value
+		}
+	}
+	return total
+}
+
+// updateRefundedStatus moves the order to (partially) refunded once refunds have settled.
+func (s *Simulator) updateRefundedStatus(order *dto.PineOrderData) {
+	var settled money.Amount
+	for _, refund := range order.Refunds {
+		if refund.Status == "PROCESSED" {
+			settled += refund.OrderAmount.Value
+		}
+	}
+	switch {
+	case settled == 0:
+	case settled >= order.OrderAmount.Value:
+		s.setPineOrderStatus(order, "REFUNDED")
+	default:
+		s.setPineOrderStatus(order, "PARTIALLY_REFUNDED")
+	}
+}
+
+// setPineOrderStatus must be called with s.mu held.
+func (s *Simulator) setPineOrderStatus(order *dto.PineOrderData, status string) {
+	order.Status = status
+	order.UpdatedAt = time.Now().UTC()
+}
+
+// SetPineOrderStatus moves a simulated order to status as if the customer had paid
+// (PROCESSED, AUTHORIZED) or the payment had failed (FAILED). Paying adds a payment
+// for the full order amount.
+func (s *Simulator) SetPineOrderStatus(orderID, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.pineOrders[orderID]
+	if !ok {
+		return fmt.Errorf("order %s not found", orderID)
+	}
+
+	status = strings.ToUpper(status)
+	if (status == "PROCESSED" || status == "AUTHORIZED" || status == "FAILED") && len(order.Payments) == 0 {
+		now := time.Now().UTC()
+		order.Payments = append(order.Payments, dto.Payment{
+			ID:                       "v1-pay-" + uuid.New().String(),
+			MerchantPaymentReference: "sim-" + uuid.New().String()[:8],
+			Status:                   status,
+			PaymentAmount:            order.OrderAmount,
+			PaymentMethod:            "CARD",
+			AcquirerData: dto.AcquirerData{
+				ApprovalCode:      "000000",
+				AcquirerReference: "sim",
+				RRN:               fmt.Sprint(now.UnixNano())[:12],
+				AcquirerName:      "SIMULATOR",
+			},
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+	s.setPineOrderStatus(order, status)
+	return nil
+}
+
+// SetPineRefundStatus settles or fails a refund created with a non-final status.
+func (s *Simulator) SetPineRefundStatus(refundID, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, order := range s.pineOrders {
+		for i := range order.Refunds {
+			if order.Refunds[i].OrderID != refundID {
+				continue
+			}
+			order.Refunds[i].Status = strings.ToUpper(status)
+			order.Refunds[i].UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+			s.updateRefundedStatus(order)
+			return nil
+		}
+	}
+	return fmt.Errorf("refund %s not found", refundID)
+}
+
+func (s *Simulator) pineSetStatusHandler(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Status == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "status is required")
+		return
+	}
+
+	if err := s.SetPineOrderStatus(chi.URLParam(r, "id"), body.Status); err != nil {
+		writeError(w, http.StatusNotFound, "ORDER_NOT_FOUND", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Simulator) pineSetRefundStatusHandler(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Status == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "status is required")
+		return
+	}
+
+	if err := s.SetPineRefundStatus(chi.URLParam(r, "id"), body.Status); err != nil {
+		writeError(w, http.StatusNotFound, "REFUND_NOT_FOUND", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
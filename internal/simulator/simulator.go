@@ -0,0 +1,152 @@
+// Package simulator is an in-memory stand-in for the Pine Labs, DT One and DBS APIs.
+// It backs cmd/simulator and can be started inside tests with NewServer.
+package simulator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/aakritigkmit/payment-gateway/internal/config"
+	"github.com/aakritigkmit/payment-gateway/internal/dto"
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"github.com/aakritigkmit/payment-gateway/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Options tunes the simulated providers.
+type Options struct {
+	// ProductsPerOperator is the DT One catalog size for each simulated operator.
+	ProductsPerOperator int
+	// RefundStatus is the status Pine Labs reports for new refunds, e.g. "PENDING"
+	// to exercise asynchronous refund tracking. Defaults to "PROCESSED".
+	RefundStatus string
+}
+
+// Simulator holds the state of every simulated provider.
+type Simulator struct {
+	mu      sync.Mutex
+	opts    Options
+	baseURL string
+
+	faults []*Fault
+
+	pineOrders map[string]*dto.PineOrderData
+
+	products     []model.Product
+	transactions map[string]*model.ProductTransaction // keyed by external_id
+	callbackURLs map[string]string                    // keyed by external_id
+	nextTxID     int64
+}
+
+func New(opts Options) *Simulator {
+	if opts.ProductsPerOperator <= 0 {
+		opts.ProductsPerOperator = 150
+	}
+	if opts.RefundStatus == "" {
+		opts.RefundStatus = "PROCESSED"
+	}
+	return &Simulator{
+		opts:         opts,
+		pineOrders:   make(map[string]*dto.PineOrderData),
+		products:     buildCatalog(opts.ProductsPerOperator),
+		transactions: make(map[string]*model.ProductTransaction),
+		callbackURLs: make(map[string]string),
+		nextTxID:     1000000,
+	}
+}
+
+// SetBaseURL sets the externally visible URL used in redirect links.
+func (s *Simulator) SetBaseURL(baseURL string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.baseURL = baseURL
+}
+
+// Handler returns the HTTP handler serving every simulated API plus the /_sim control API.
+func (s *Simulator) Handler() http.Handler {
+	r := chi.NewRouter()
+	r.Use(s.applyFaults)
+
+	r.Post(config.SimulatorPineLabsTokenPath, s.pineToken)
+	r.Post(config.SimulatorPineLabsOrderPath, s.pineCreateOrder)
+	r.Get(config.SimulatorPineLabsPayOrderPath+"/{id}", s.pineGetOrder)
+	r.Put(config.SimulatorPineLabsPayOrderPath+"/{id}/capture", s.pineCaptureOrder)
+	r.Put(config.SimulatorPineLabsPayOrderPath+"/{id}/cancel", s.pineCancelOrder)
+	r.Post(config.SimulatorPineLabsRefundPath+"/{id}", s.pineRefund)
+
+	r.Get(config.SimulatorDtOnePath+utils.DTOneProductsPath, s.dtOneProducts)
+	r.Post(config.SimulatorDtOnePath+utils.DTOneAsyncTransactionsPath, s.dtOneCreateTransaction)
+	r.Get(config.SimulatorDtOnePath+utils.DTOneTransactionsPath, s.dtOneGetTransactions)
+	r.Post(config.SimulatorDtOnePath+utils.DTOneAsyncTransactionsPath+"/{id}/confirm", s.dtOneConfirmTransaction)
+	r.Post(config.SimulatorDtOnePath+utils.DTOneTransactionsPath+"/{id}/cancel", s.dtOneCancelTransaction)
+
+	r.Route("/_sim", func(r chi.Router) {
+		r.Get("/faults", s.listFaultsHandler)
+		r.Post("/faults", s.addFaultHandler)
+		r.Delete("/faults", s.clearFaultsHandler)
+		r.Post("/pinelabs/orders/{id}/status", s.pineSetStatusHandler)
+		r.Post("/pinelabs/refunds/{id}/status", s.pineSetRefundStatusHandler)
+		r.Post("/dbs/push", s.dbsPushHandler)
+	})
+
+	return r
+}
+
+// Server is a simulator listening on a local httptest server.
+type Server struct {
+	*httptest.Server
+	Sim *Simulator
+}
+
+// NewServer starts a simulator on a random local port. Call Close when done.
+func NewServer(opts Options) *Server {
+	sim := New(opts)
+	srv := httptest.NewServer(sim.Handler())
+	sim.SetBaseURL(srv.URL)
+	return &Server{Server: srv, Sim: sim}
+}
+
+// Configure points every provider URL in cfg at this server and fills in dummy credentials.
+func (srv *Server) Configure(cfg *config.Config) {
+	ConfigureURLs(cfg, srv.URL)
+}
+
+// ConfigureURLs points every provider URL in cfg at a simulator running at baseURL.
+func ConfigureURLs(cfg *config.Config, baseURL string) {
+	cfg.PinelabsTokenURL = baseURL + config.SimulatorPineLabsTokenPath
+	cfg.PinelabsOrderURL = baseURL + config.SimulatorPineLabsOrderPath
+	cfg.PinelabsGetOrderURL = baseURL + config.SimulatorPineLabsPayOrderPath
+	cfg.PinelabsRefundURL = baseURL + config.SimulatorPineLabsRefundPath
+	cfg.PinelabsCaptureURL = baseURL + config.SimulatorPineLabsPayOrderPath
+	cfg.PinelabsCancelURL = baseURL + config.SimulatorPineLabsPayOrderPath
+	cfg.DtOneBaseURL = baseURL + config.SimulatorDtOnePath
+
+	if cfg.PinelabsClientID == "" {
+		cfg.PinelabsClientID = "simulator"
+		cfg.PinelabsClientSecret = "simulator"
+	}
+	if cfg.DtOneUsername == "" {
+		cfg.DtOneUsername = "simulator"
+		cfg.DtOnePassword = "simulator"
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeError uses the {code, message} error shape Pine Labs returns.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]string{"code": code, "message": message})
+}
+
+// writeDTOneError uses DT One's {"errors": [{code, message}]} error shape.
+func writeDTOneError(w http.ResponseWriter, status, code int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"errors": []utils.DTOneErrorDetail{{Code: code, Message: message}},
+	})
+}
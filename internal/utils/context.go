@@ -0,0 +1,46 @@
+package utils
+
+import "context"
+
+type contextKey string
+
+const (
+	userIDContextKey      contextKey = "user_id"
+	userRoleContextKey    contextKey = "role"
+	idempotencyContextKey contextKey = "idempotency_key"
+)
+
+// WithUserID returns a copy of ctx carrying the authenticated user's ID.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDContextKey, userID)
+}
+
+// UserIDFromContext returns the authenticated user's ID, or "" if there is none.
+func UserIDFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDContextKey).(string)
+	return userID
+}
+
+// WithUserRole returns a copy of ctx carrying the authenticated user's role.
+func WithUserRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, userRoleContextKey, role)
+}
+
+// UserRoleFromContext returns the authenticated user's role, or "" if there is none.
+func UserRoleFromContext(ctx context.Context) string {
+	role, _ := ctx.Value(userRoleContextKey).(string)
+	return role
+}
+
+// WithIdempotencyKey returns a copy of ctx carrying the request's caller-scoped
+// Idempotency-Key.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyContextKey, key)
+}
+
+// IdempotencyKeyFromContext returns the request's caller-scoped Idempotency-Key, or ""
+// if it was sent without one.
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyContextKey).(string)
+	return key
+}
@@ -5,22 +5,124 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aakritigkmit/payment-gateway/internal/config"
 	"github.com/aakritigkmit/payment-gateway/internal/dto"
 	"github.com/aakritigkmit/payment-gateway/internal/model"
 )
 
-func FetchDTOneProducts(ctx context.Context, page, perPage int, filter dto.ProductSyncRequest) ([]model.Product, int, error) {
-	cfg := config.GetConfig()
+// DT One API paths, relative to the configured base URL.
+const (
+	DTOneProductsPath          = "/v1/products"
+	DTOneAsyncTransactionsPath = "/v1/async/transactions"
+	DTOneTransactionsPath      = "/v1/transactions"
+)
+
+// Backoff between retries when DT One sends no Retry-After; variables so tests can shorten them.
+var (
+	dtOneRetryBaseDelay = time.Second
+	dtOneRetryMaxDelay  = 30 * time.Second
+)
+
+// DTOneErrorDetail is one entry of the "errors" array in a DT One error response.
+type DTOneErrorDetail struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// APIError is a non-2xx response from DT One.
+type APIError struct {
+	StatusCode int
+	Errors     []DTOneErrorDetail
+	// RetryAfter is the delay DT One asked for via the Retry-After header, if any.
+	RetryAfter time.Duration
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("dt one: status %d: %s", e.StatusCode, strings.TrimSpace(e.Body))
+	}
+	parts := make([]string, 0, len(e.Errors))
+	for _, detail := range e.Errors {
+		parts = append(parts, fmt.Sprintf("%d %s", detail.Code, detail.Message))
+	}
+	return fmt.Sprintf("dt one: status %d: %s", e.StatusCode, strings.Join(parts, "; "))
+}
+
+// HasCode reports whether DT One returned the given error code.
+func (e *APIError) HasCode(code int) bool {
+	for _, detail := range e.Errors {
+		if detail.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Retryable reports whether the request may succeed if sent again later.
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// IsDTOneStatus reports whether err is a DT One APIError with the given status code.
+func IsDTOneStatus(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+// DTOneClient calls the DT One API. It is safe for concurrent use.
+type DTOneClient struct {
+	baseURL    string
+	authHeader string
+	httpClient *http.Client
+	maxRetries int
+	// callbackURL carries the callback credentials as userinfo, the way DT One expects.
+	callbackURL string
+}
+
+// NewDTOneClient builds a client from cfg. httpClient may be nil, in which case a
+// client with cfg.DtOneTimeout is used; tests can pass one wired to a fake server.
+func NewDTOneClient(cfg *config.Config, httpClient *http.Client) *DTOneClient {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: cfg.DtOneTimeout}
+	}
+	credentials := base64.StdEncoding.EncodeToString([]byte(cfg.DtOneUsername + ":" + cfg.DtOnePassword))
+	return &DTOneClient{
+		baseURL:     strings.TrimRight(cfg.DtOneBaseURL, "/"),
+		authHeader:  "Basic " + credentials,
+		httpClient:  httpClient,
+		maxRetries:  cfg.DtOneMaxRetries,
+		callbackURL: dtOneCallbackURL(cfg),
+	}
+}
 
-	auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", cfg.DtOneUsername, cfg.DtOnePassword)))
+func dtOneCallbackURL(cfg *config.Config) string {
+	if cfg.DtOneCallbackURL == "" {
+		return ""
+	}
+	u, err := url.Parse(cfg.DtOneCallbackURL)
+	if err != nil {
+		log.Printf("[DTOne] Ignoring invalid callback URL: %v", err)
+		return ""
+	}
+	if cfg.DtOneCallbackUsername != "" {
+		u.User = url.UserPassword(cfg.DtOneCallbackUsername, cfg.DtOneCallbackPassword)
+	}
+	return u.String()
+}
 
+// FetchProducts returns one page of products and the total number of pages.
+func (c *DTOneClient) FetchProducts(ctx context.Context, page, perPage int, filter dto.ProductSyncRequest) ([]model.Product, int, error) {
 	params := url.Values{}
 	params.Set("per_page", strconv.Itoa(perPage))
 	params.Set("page", strconv.Itoa(page))
@@ -34,105 +136,177 @@ func FetchDTOneProducts(ctx context.Context, page, perPage int, filter dto.Produ
 	if filter.Type != "" {
 		params.Set("type", filter.Type)
 	}
-
 	if filter.OperatorID != 0 {
 		params.Set("operator_id", strconv.Itoa(filter.OperatorID))
 	}
 
-	fullURL := fmt.Sprintf("%s?%s", cfg.DtOneProductsURL, params.Encode())
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	var products []model.Product
+	header, err := c.do(ctx, http.MethodGet, DTOneProductsPath, params, nil, &products)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to create HTTP request: %w", err)
+		return nil, 0, err
 	}
-	req.Header.Set("Authorization", "Basic "+auth)
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	totalPages, err := strconv.Atoi(header.Get("X-Total-Pages"))
 	if err != nil {
-		return nil, 0, fmt.Errorf("HTTP request failed: %w", err)
+		return nil, 0, fmt.Errorf("invalid total pages header: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, 0, fmt.Errorf("status: %d, body: %s", resp.StatusCode, body)
-	}
+	return products, totalPages, nil
+}
 
-	var products []model.Product
-	if err := json.NewDecoder(resp.Body).Decode(&products); err != nil {
-		return nil, 0, fmt.Errorf("decode error: %w", err)
+// CreateTransaction submits an asynchronous transaction. Status changes are sent to the
+// configured callback URL unless req names another.
+func (c *DTOneClient) CreateTransaction(ctx context.Context, req dto.DTOneTransactionRequest) (model.ProductTransaction, error) {
+	if req.CallbackURL == "" {
+		req.CallbackURL = c.callbackURL
 	}
-
-	totalPagesStr := resp.Header.Get("X-Total-Pages")
-	totalPages, err := strconv.Atoi(totalPagesStr)
-	if err != nil {
-		return nil, 0, fmt.Errorf("invalid total pages header: %w", err)
+	var tx model.ProductTransaction
+	if _, err := c.do(ctx, http.MethodPost, DTOneAsyncTransactionsPath, nil, req, &tx); err != nil {
+		return model.ProductTransaction{}, err
 	}
+	return tx, nil
+}
 
-	return products, totalPages, nil
+// ConfirmTransaction confirms a transaction created without auto-confirm.
+func (c *DTOneClient) ConfirmTransaction(ctx context.Context, id int64) (model.ProductTransaction, error) {
+	var tx model.ProductTransaction
+	path := fmt.Sprintf("%s/%d/confirm", DTOneAsyncTransactionsPath, id)
+	if _, err := c.do(ctx, http.MethodPost, path, nil, nil, &tx); err != nil {
+		return model.ProductTransaction{}, err
+	}
+	return tx, nil
 }
 
-func CreateDTOneTransaction(ctx context.Context, externalID string, productID int, mobileNumber string) error {
-	cfg := config.GetConfig()
-	payload := map[string]interface{}{
-		"external_id": externalID,
-		"product_id":  productID,
-		"credit_party_identifier": map[string]string{
-			"mobile_number": mobileNumber,
-		},
-		"auto_confirm": true,
+// CancelTransaction cancels a transaction that has not been confirmed.
+func (c *DTOneClient) CancelTransaction(ctx context.Context, id int64) (model.ProductTransaction, error) {
+	var tx model.ProductTransaction
+	path := fmt.Sprintf("%s/%d/cancel", DTOneTransactionsPath, id)
+	if _, err := c.do(ctx, http.MethodPost, path, nil, nil, &tx); err != nil {
+		return model.ProductTransaction{}, err
 	}
-	data, _ := json.Marshal(payload)
+	return tx, nil
+}
 
-	auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", cfg.DtOneUsername, cfg.DtOnePassword)))
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.DtOneTransactionURL, bytes.NewBuffer(data))
-	if err != nil {
-		return err
+// FetchTransactionByExternalID looks transactions up by the external ID they were created with.
+func (c *DTOneClient) FetchTransactionByExternalID(ctx context.Context, externalID string) ([]model.ProductTransaction, error) {
+	params := url.Values{}
+	params.Set("external_id", externalID)
+
+	var txs []model.ProductTransaction
+	if _, err := c.do(ctx, http.MethodGet, DTOneTransactionsPath, params, nil, &txs); err != nil {
+		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Basic "+auth)
+	return txs, nil
+}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
+// do sends a request and decodes a 2xx JSON response into out, returning the response
+// headers. 429s are retried for every method, honouring Retry-After; 5xx responses and
+// network errors only for GETs, since a POST may already have been applied.
+func (c *DTOneClient) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) (http.Header, error) {
+	fullURL := c.baseURL + path
+	if len(query) > 0 {
+		fullURL += "?" + query.Encode()
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to create transaction, status: %d, body: %s", resp.StatusCode, string(body))
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
 	}
 
-	return nil
+	for attempt := 0; ; attempt++ {
+		header, err := c.send(ctx, method, fullURL, payload, out)
+		if err == nil {
+			return header, nil
+		}
+
+		var apiErr *APIError
+		isAPIErr := errors.As(err, &apiErr)
+		retryable := (isAPIErr && apiErr.StatusCode == http.StatusTooManyRequests) ||
+			(method == http.MethodGet && (!isAPIErr || apiErr.Retryable()))
+		if !retryable || attempt >= c.maxRetries || ctx.Err() != nil {
+			return nil, err
+		}
+
+		delay := dtOneRetryBaseDelay << attempt
+		if delay > dtOneRetryMaxDelay {
+			delay = dtOneRetryMaxDelay
+		}
+		if isAPIErr && apiErr.RetryAfter > 0 {
+			delay = apiErr.RetryAfter
+		}
+		log.Printf("[DTOne] %s %s failed (attempt %d): %v; retrying in %v", method, path, attempt+1, err, delay)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
 }
 
-func FetchDTOneTransactionByExternalID(ctx context.Context, externalID string) ([]model.ProductTransaction, error) {
-	cfg := config.GetConfig()
-	auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", cfg.DtOneUsername, cfg.DtOnePassword)))
-	url := fmt.Sprintf("%s?external_id=%s", cfg.DtOneGetTransactionURL, externalID)
+func (c *DTOneClient) send(ctx context.Context, method, fullURL string, payload []byte, out interface{}) (http.Header, error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, reader)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
+	req.Header.Set("Authorization", c.authHeader)
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Basic "+auth)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	var txs []model.ProductTransaction
-	if err := json.NewDecoder(resp.Body).Decode(&txs); err != nil {
-		return nil, err
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, newAPIError(resp)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch transaction: status %d", resp.StatusCode)
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("decode error: %w", err)
+		}
 	}
+	return resp.Header, nil
+}
 
-	return txs, nil
+func newAPIError(resp *http.Response) *APIError {
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Body:       string(raw),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	var parsed struct {
+		Errors []DTOneErrorDetail `json:"errors"`
+	}
+	if json.Unmarshal(raw, &parsed) == nil {
+		apiErr.Errors = parsed.Errors
+	}
+	return apiErr
+}
+
+// parseRetryAfter accepts both forms of Retry-After: delta seconds or an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(at); delay > 0 {
+			return delay
+		}
+	}
+	return 0
 }
@@ -0,0 +1,160 @@
+package utils_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aakritigkmit/payment-gateway/internal/config"
+	"github.com/aakritigkmit/payment-gateway/internal/dto"
+	"github.com/aakritigkmit/payment-gateway/internal/model"
+	"github.com/aakritigkmit/payment-gateway/internal/simulator"
+	"github.com/aakritigkmit/payment-gateway/internal/utils"
+)
+
+var (
+	productsPath     = config.SimulatorDtOnePath + utils.DTOneProductsPath
+	transactionsPath = config.SimulatorDtOnePath + utils.DTOneAsyncTransactionsPath
+)
+
+// newDTOneClient starts a simulator and returns a client pointed at it.
+func newDTOneClient(t *testing.T, maxRetries int) (*utils.DTOneClient, *simulator.Server) {
+	t.Helper()
+	srv := simulator.NewServer(simulator.Options{ProductsPerOperator: 2})
+	t.Cleanup(srv.Close)
+	t.Cleanup(utils.SetDTOneRetryDelays(time.Millisecond, 10*time.Millisecond))
+
+	cfg := &config.Config{DtOneTimeout: 5 * time.Second, DtOneMaxRetries: maxRetries}
+	srv.Configure(cfg)
+	return utils.NewDTOneClient(cfg, srv.Client()), srv
+}
+
+// faultHits returns how many requests the simulator's scripted faults have answered.
+func faultHits(t *testing.T, srv *simulator.Server) int {
+	t.Helper()
+	resp, err := srv.Client().Get(srv.URL + "/_sim/faults")
+	if err != nil {
+		t.Fatalf("list faults: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var faults []simulator.Fault
+	if err := json.NewDecoder(resp.Body).Decode(&faults); err != nil {
+		t.Fatalf("decode faults: %v", err)
+	}
+	hits := 0
+	for _, f := range faults {
+		hits += f.Hits
+	}
+	return hits
+}
+
+// fixedValueProduct returns a simulated product that needs no amount.
+func fixedValueProduct(t *testing.T, client *utils.DTOneClient) model.Product {
+	t.Helper()
+	products, _, err := client.FetchProducts(context.Background(), 1, 100, dto.ProductSyncRequest{})
+	if err != nil {
+		t.Fatalf("fetch products: %v", err)
+	}
+	for _, p := range products {
+		if !p.IsRanged() {
+			return p
+		}
+	}
+	t.Fatal("simulator returned no fixed-value product")
+	return model.Product{}
+}
+
+func TestFetchProductsRetriesServerErrors(t *testing.T) {
+	client, srv := newDTOneClient(t, 4)
+	srv.Sim.AddFault(simulator.Fault{Method: http.MethodGet, PathPrefix: productsPath, Status: http.StatusServiceUnavailable, Times: 2})
+
+	products, totalPages, err := client.FetchProducts(context.Background(), 1, 10, dto.ProductSyncRequest{})
+	if err != nil {
+		t.Fatalf("FetchProducts: %v", err)
+	}
+	if len(products) == 0 || totalPages == 0 {
+		t.Fatalf("got %d products, %d pages; want a non-empty page", len(products), totalPages)
+	}
+	if hits := faultHits(t, srv); hits != 2 {
+		t.Errorf("fault hits = %d, want 2", hits)
+	}
+}
+
+func TestFetchProductsStopsAfterMaxRetries(t *testing.T) {
+	client, srv := newDTOneClient(t, 2)
+	srv.Sim.AddFault(simulator.Fault{Method: http.MethodGet, PathPrefix: productsPath, Status: http.StatusInternalServerError})
+
+	_, _, err := client.FetchProducts(context.Background(), 1, 10, dto.ProductSyncRequest{})
+	if !utils.IsDTOneStatus(err, http.StatusInternalServerError) {
+		t.Fatalf("err = %v, want a 500 APIError", err)
+	}
+	if hits := faultHits(t, srv); hits != 3 {
+		t.Errorf("fault hits = %d, want 3 (one try plus two retries)", hits)
+	}
+}
+
+func TestCreateTransactionDoesNotRetryServerErrors(t *testing.T) {
+	client, srv := newDTOneClient(t, 4)
+	product := fixedValueProduct(t, client)
+	srv.Sim.AddFault(simulator.Fault{Method: http.MethodPost, PathPrefix: transactionsPath, Status: http.StatusServiceUnavailable, Times: 1})
+
+	_, err := client.CreateTransaction(context.Background(), dto.DTOneTransactionRequest{ExternalID: "no-retry", ProductID: product.UniqueId})
+	var apiErr *utils.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("err = %v, want a 503 APIError", err)
+	}
+	if !apiErr.Retryable() {
+		t.Error("503 APIError should report Retryable")
+	}
+	if hits := faultHits(t, srv); hits != 1 {
+		t.Errorf("fault hits = %d, want 1; a POST must not be resent after a 5xx", hits)
+	}
+}
+
+func TestCreateTransactionHonoursRetryAfter(t *testing.T) {
+	client, srv := newDTOneClient(t, 4)
+	product := fixedValueProduct(t, client)
+	srv.Sim.AddFault(simulator.Fault{Method: http.MethodPost, PathPrefix: transactionsPath, Status: http.StatusTooManyRequests, RetryAfter: 1, Times: 1})
+
+	start := time.Now()
+	tx, err := client.CreateTransaction(context.Background(), dto.DTOneTransactionRequest{ExternalID: "rate-limited", ProductID: product.UniqueId})
+	if err != nil {
+		t.Fatalf("CreateTransaction: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want at least the 1s Retry-After", elapsed)
+	}
+	if tx.ExternalID != "rate-limited" {
+		t.Errorf("ExternalID = %q, want %q", tx.ExternalID, "rate-limited")
+	}
+	if hits := faultHits(t, srv); hits != 1 {
+		t.Errorf("fault hits = %d, want 1", hits)
+	}
+}
+
+func TestAPIErrorCarriesDTOneErrorCodes(t *testing.T) {
+	client, _ := newDTOneClient(t, 4)
+
+	_, err := client.CreateTransaction(context.Background(), dto.DTOneTransactionRequest{ExternalID: "unknown-product", ProductID: 1})
+	var apiErr *utils.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want an APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || !utils.IsDTOneStatus(err, http.StatusBadRequest) {
+		t.Errorf("StatusCode = %d, want 400", apiErr.StatusCode)
+	}
+	if !apiErr.HasCode(1003001) {
+		t.Errorf("Errors = %+v, want code 1003001", apiErr.Errors)
+	}
+	if apiErr.Retryable() {
+		t.Error("400 APIError should not report Retryable")
+	}
+	if !strings.Contains(apiErr.Error(), "Product not found") {
+		t.Errorf("Error() = %q, want the DT One message", apiErr.Error())
+	}
+}
@@ -0,0 +1,13 @@
+package utils
+
+import "time"
+
+// SetDTOneRetryDelays shortens the DT One retry backoff for a test and returns a
+// function restoring the previous values.
+func SetDTOneRetryDelays(base, max time.Duration) func() {
+	prevBase, prevMax := dtOneRetryBaseDelay, dtOneRetryMaxDelay
+	dtOneRetryBaseDelay, dtOneRetryMaxDelay = base, max
+	return func() {
+		dtOneRetryBaseDelay, dtOneRetryMaxDelay = prevBase, prevMax
+	}
+}
@@ -8,7 +8,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func GenerateJWT(userID string, email string) (string, error) {
+func GenerateJWT(userID string, email string, role string) (string, error) {
 	secret := os.Getenv("JWT_SECRET")
 	if secret == "" {
 		fmt.Println("JWT_SECRET is not set") // Print error
@@ -18,6 +18,7 @@ func GenerateJWT(userID string, email string) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID,
 		"email":   email,
+		"role":    role,
 		"exp":     time.Now().Add(time.Hour * 24).Unix(), // Token expires in 24 hours
 	}
 	// MapClaims: map[string]interface{} that is used to store the claims (data) within a JWT
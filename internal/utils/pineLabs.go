@@ -15,6 +15,14 @@ import (
 	"github.com/aakritigkmit/payment-gateway/internal/dto"
 )
 
+// ErrPineLabsUnavailable marks failures where Pine Labs could not be reached or
+// returned a 5xx, as opposed to rejecting the request itself.
+var ErrPineLabsUnavailable = errors.New("pine labs unavailable")
+
+// ErrPineLabsRejected marks a 4xx answer: Pine Labs received the request and
+// definitely did not act on it.
+var ErrPineLabsRejected = errors.New("pine labs rejected the request")
+
 type TokenResponse struct {
 	AccessToken string `json:"access_token"`
 }
@@ -49,11 +57,18 @@ func FetchAccessToken(ctx context.Context) (TokenResponse, error) {
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := http.DefaultClient.Do(req)
-	if err != nil || resp.StatusCode != http.StatusOK {
-		return TokenResponse{}, errors.New("token fetch failed")
+	if err != nil {
+		return TokenResponse{}, fmt.Errorf("%w: token fetch failed: %v", ErrPineLabsUnavailable, err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return TokenResponse{}, fmt.Errorf("%w: token fetch failed: status %d", ErrPineLabsUnavailable, resp.StatusCode)
+		}
+		return TokenResponse{}, errors.New("token fetch failed")
+	}
+
 	var token TokenResponse
 	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
 		return TokenResponse{}, err
@@ -79,10 +94,15 @@ func CreateOrderRequest(ctx context.Context, token string, jsonPayload []byte) (
 
 	resp, err := http.DefaultClient.Do(request)
 	if err != nil {
-		return OrderAPIResponse{}, fmt.Errorf("HTTP request failed: %w", err)
+		return OrderAPIResponse{}, fmt.Errorf("%w: HTTP request failed: %v", ErrPineLabsUnavailable, err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode >= http.StatusInternalServerError {
+		body, _ := io.ReadAll(resp.Body)
+		return OrderAPIResponse{}, fmt.Errorf("%w: order creation failed: status %d, body: %s", ErrPineLabsUnavailable, resp.StatusCode, body)
+	}
+
 	var response OrderAPIResponse
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
 		return OrderAPIResponse{}, fmt.Errorf("failed to decode response: %w", err)
@@ -146,13 +166,19 @@ func CreateRefundRequest(ctx context.Context, accessToken, orderID string, paylo
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: HTTP request failed: %v", ErrPineLabsUnavailable, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("refund API error: %s", string(body))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return nil, fmt.Errorf("%w: refund API error: status %d, body: %s", ErrPineLabsUnavailable, resp.StatusCode, body)
+		}
+		if resp.StatusCode >= http.StatusBadRequest {
+			return nil, fmt.Errorf("%w: refund API error: status %d, body: %s", ErrPineLabsRejected, resp.StatusCode, body)
+		}
+		return nil, fmt.Errorf("refund API error: status %d, body: %s", resp.StatusCode, body)
 	}
 
 	var refundResp dto.RefundOrderResponse
@@ -162,3 +188,48 @@ func CreateRefundRequest(ctx context.Context, accessToken, orderID string, paylo
 
 	return &refundResp, nil
 }
+
+// CaptureOrderRequest captures a pre-authorized order, fully or partially.
+func CaptureOrderRequest(ctx context.Context, accessToken, orderID string, payload []byte) (*dto.PineOrderResponse, error) {
+	cfg := config.GetConfig()
+	url := fmt.Sprintf("%s/%s/capture", cfg.PinelabsCaptureURL, orderID)
+	return sendPineLabsOrderAction(ctx, accessToken, url, payload)
+}
+
+// CancelOrderRequest cancels an order, releasing any pre-authorized amount.
+func CancelOrderRequest(ctx context.Context, accessToken, orderID string) (*dto.PineOrderResponse, error) {
+	cfg := config.GetConfig()
+	url := fmt.Sprintf("%s/%s/cancel", cfg.PinelabsCancelURL, orderID)
+	return sendPineLabsOrderAction(ctx, accessToken, url, nil)
+}
+
+func sendPineLabsOrderAction(ctx context.Context, accessToken, url string, payload []byte) (*dto.PineOrderResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: HTTP request failed: %v", ErrPineLabsUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return nil, fmt.Errorf("%w: status %d, body: %s", ErrPineLabsUnavailable, resp.StatusCode, body)
+		}
+		return nil, fmt.Errorf("order action failed: status %d, body: %s", resp.StatusCode, body)
+	}
+
+	var result dto.PineOrderResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/aakritigkmit/payment-gateway/internal/config"
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -40,6 +41,12 @@ func SetRedisKey(ctx context.Context, key string, value interface{}, expiration
 	return RedisClient.Set(ctx, key, value, expiration).Err()
 }
 
+// SetRedisKeyNX sets a value only if the key does not already exist.
+// It reports whether the key was set.
+func SetRedisKeyNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return RedisClient.SetNX(ctx, key, value, expiration).Result()
+}
+
 // GetRedisKey retrieves a value from Redis by key
 func GetRedisKey(ctx context.Context, key string) (string, error) {
 	return RedisClient.Get(ctx, key).Result()
@@ -49,3 +56,31 @@ func GetRedisKey(ctx context.Context, key string) (string, error) {
 func DeleteRedisKey(ctx context.Context, key string) error {
 	return RedisClient.Del(ctx, key).Err()
 }
+
+// Compare-and-act scripts for locks: only the holder of the token may extend or release.
+var (
+	releaseLockScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`)
+	extendLockScript  = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) end return 0`)
+)
+
+// AcquireRedisLock takes key for ttl with a random token. It reports false, without
+// error, when someone else holds the lock.
+func AcquireRedisLock(ctx context.Context, key string, ttl time.Duration) (string, bool, error) {
+	token := uuid.NewString()
+	ok, err := RedisClient.SetNX(ctx, key, token, ttl).Result()
+	if err != nil || !ok {
+		return "", false, err
+	}
+	return token, true, nil
+}
+
+// ExtendRedisLock resets the lock's TTL. It reports false if the lock was lost.
+func ExtendRedisLock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	n, err := extendLockScript.Run(ctx, RedisClient, []string{key}, token, ttl.Milliseconds()).Int()
+	return n == 1, err
+}
+
+// ReleaseRedisLock deletes the lock if token still holds it.
+func ReleaseRedisLock(ctx context.Context, key, token string) error {
+	return releaseLockScript.Run(ctx, RedisClient, []string{key}, token).Err()
+}
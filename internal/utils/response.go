@@ -3,6 +3,8 @@ package utils
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/aakritigkmit/payment-gateway/internal/dto"
 )
 
 // Response defines the structure for all API responses
@@ -33,3 +35,14 @@ func SendErrorResponse(w http.ResponseWriter, statusCode int, message string) {
 	})
 
 }
+
+// SendValidationErrorResponse sends a 422 listing the fields that failed validation
+func SendValidationErrorResponse(w http.ResponseWriter, message string, fields []dto.FieldError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(Response{
+		Success: false,
+		Message: message,
+		Data:    map[string]interface{}{"errors": fields},
+	})
+}
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// ComputeHMACSHA256 returns the hex-encoded HMAC-SHA256 of message using secret.
+func ComputeHMACSHA256(secret string, message []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(message)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyHMACSHA256 checks a hex-encoded HMAC-SHA256 signature in constant time.
+func VerifyHMACSHA256(secret string, message []byte, signature string) bool {
+	expected, err := hex.DecodeString(ComputeHMACSHA256(secret, message))
+	if err != nil {
+		return false
+	}
+	actual, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(expected, actual)
+}